@@ -0,0 +1,171 @@
+package log
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fatih/structs"
+	"golang.org/x/text/cases"
+	"golang.org/x/text/language"
+)
+
+// legacyGetLevelConfig is the level config lookup used before levelSlots were introduced
+func legacyGetLevelConfig(level LogLevel) LevelConfig {
+	caser := cases.Title(language.AmericanEnglish)
+	lvlName := caser.String(strings.ToLower(level.String()))
+
+	s := structs.New(config)
+	lvlField := s.Field(lvlName)
+	return lvlField.Value().(LevelConfig)
+}
+
+// legacyGetLogLevel is the level detection used before the type switch was introduced
+func legacyGetLogLevel(values ...interface{}) (LogLevel, []interface{}) {
+	comp := LogLevel(0)
+	level := defaultLevel
+
+	if reflect.TypeOf(values[0]) == reflect.TypeOf(comp) {
+		level = LogLevel(reflect.ValueOf(values[0]).Uint())
+		values = values[1:]
+	}
+	return level, values
+}
+
+// legacyStringify is the message formatting used before pooled buffers were introduced
+func legacyStringify(message Message) string {
+	cfg := legacyGetLevelConfig(message.Level)
+
+	prefix := ""
+	caller := ""
+
+	if showTimestamp {
+		prefix = fmt.Sprintf("%s ", message.Time.Format(timeFormat))
+	}
+	prefix += fmt.Sprintf("[%s]", message.Level.String())
+
+	if cfg.ShowFilePath || cfg.ShowFunctionName || cfg.ShowLineNumber {
+		caller += "["
+		if cfg.ShowFilePath {
+			caller += fmt.Sprintf("%s:", message.Caller.Path)
+		}
+		if cfg.ShowFunctionName {
+			caller += fmt.Sprintf("%s", message.Caller.FunctionName)
+		}
+		if cfg.ShowLineNumber {
+			caller += fmt.Sprintf(":%d", message.Caller.LineNumber)
+		}
+		caller += "]"
+	}
+	return fmt.Sprintf("%s%s %s", prefix, caller, message.Message)
+}
+
+func benchmarkMessage() Message {
+	return Message{
+		Time:    time.Now(),
+		Level:   ERROR,
+		Caller:  Caller{Path: "bench_test.go", FunctionName: "Benchmark", LineNumber: 42},
+		Message: "benchmark message\n",
+	}
+}
+
+func TestDisabledLevelDoesNotAllocate(t *testing.T) {
+	defer SetLogLevel(VERBOSE)
+	SetLogLevel(INFO)
+
+	allocs := testing.AllocsPerRun(100, func() {
+		Println(DEBUG, "disabled")
+		Printf(DEBUG, "disabled %s", "message")
+	})
+	if allocs != 0 {
+		t.Errorf("expected 0 allocations for disabled levels, got %v", allocs)
+	}
+}
+
+func TestStringifyMatchesLegacy(t *testing.T) {
+	defer SetLevelConfig(DefaultLevelConfig())
+	SetLevelConfig(DefaultLevelConfig())
+	ShowColors(false)
+	defer ShowColors(true)
+
+	message := benchmarkMessage()
+	if got, expected := stringify(message), legacyStringify(message); got != expected {
+		t.Errorf("expected %q, got %q", expected, got)
+	}
+}
+
+func BenchmarkDisabledLevel(b *testing.B) {
+	defer SetLogLevel(VERBOSE)
+	SetLogLevel(INFO)
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		Println(DEBUG, "disabled")
+	}
+}
+
+func BenchmarkDisabledLevelParallel(b *testing.B) {
+	defer SetLogLevel(VERBOSE)
+	SetLogLevel(INFO)
+
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			Println(DEBUG, "disabled")
+		}
+	})
+}
+
+func BenchmarkGetLevelConfig(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		getLevelConfig(ERROR)
+	}
+}
+
+func BenchmarkGetLevelConfigLegacy(b *testing.B) {
+	getLevelConfig(ERROR)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		legacyGetLevelConfig(ERROR)
+	}
+}
+
+func BenchmarkGetLogLevel(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		getLogLevel(false, ERROR, "message")
+	}
+}
+
+func BenchmarkGetLogLevelLegacy(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		legacyGetLogLevel(ERROR, "message")
+	}
+}
+
+func BenchmarkStringify(b *testing.B) {
+	ShowColors(false)
+	defer ShowColors(true)
+	message := benchmarkMessage()
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		stringify(message)
+	}
+}
+
+func BenchmarkStringifyLegacy(b *testing.B) {
+	ShowColors(false)
+	defer ShowColors(true)
+	getLevelConfig(ERROR)
+	message := benchmarkMessage()
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		legacyStringify(message)
+	}
+}
@@ -0,0 +1,20 @@
+//go:build go1.18
+// +build go1.18
+
+package log
+
+import "runtime/debug"
+
+// readVCSRevision reads the VCS revision from the build info
+func readVCSRevision() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return ""
+	}
+	for _, setting := range info.Settings {
+		if setting.Key == "vcs.revision" {
+			return setting.Value
+		}
+	}
+	return ""
+}
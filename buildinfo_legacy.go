@@ -0,0 +1,9 @@
+//go:build !go1.18
+// +build !go1.18
+
+package log
+
+// readVCSRevision returns an empty string, the VCS revision is only part of the build info since Go 1.18
+func readVCSRevision() string {
+	return ""
+}
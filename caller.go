@@ -0,0 +1,369 @@
+package log
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"runtime"
+	"runtime/debug"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// maxCallerSearch is the maximum number of frames searched for a caller which is not marked as helper
+const maxCallerSearch = 32
+
+// callerEntry is the resolved frame of a program counter
+type callerEntry struct {
+	function string
+	caller   Caller
+	ok       bool
+}
+
+// maxCallerCacheSize limits the number of cached program counters, the cache is cleared when it is full
+const maxCallerCacheSize = 4096
+
+// callerCacheMap maps program counters to their resolved callerEntry.
+// Every reset of the cache creates a new callerCacheMap, so entries resolved before a reset are never stored
+// in the current cache.
+type callerCacheMap struct {
+	entries sync.Map
+	size    int64
+}
+
+// callerCache holds the current *callerCacheMap, lookups are lock-free and do not allocate
+var callerCache atomic.Value
+
+// helpers contains the names of all functions marked with Helper, the map is copy-on-write
+var helpers atomic.Value
+
+// cacheMu guards writes to helpers
+var cacheMu sync.Mutex
+
+var workingDir string
+var workingDirOnce sync.Once
+
+var mainModule string
+var mainPackage string
+var mainModuleOnce sync.Once
+
+// moduleRoots caches the module root directory of the directories of main packages
+var moduleRoots sync.Map
+
+// CallerPathMode defines how the file path of the caller is formatted
+type CallerPathMode uint
+
+const (
+	// PathRelative formats the path relative to the working directory
+	PathRelative CallerPathMode = iota
+	// PathModule formats the path relative to the root of the main module.
+	// Files of other modules are formatted like PathPackage.
+	PathModule
+	// PathPackage formats the path as import path of the package followed by the file name.
+	// The import path of the main package is read from the build info or the go.mod file of its module.
+	PathPackage
+	// PathBase formats the path as file name only
+	PathBase
+	// PathAbsolute formats the path as absolute path
+	PathAbsolute
+	// PathTrimmed formats the path with the GOROOT and GOPATH prefixes removed
+	PathTrimmed
+)
+
+var callerPathMode = PathRelative
+var showQualifiedFunctionName = false
+
+// SetCallerPathMode defines how the file path of the caller is formatted.
+//
+// Default is PathRelative
+func SetCallerPathMode(mode CallerPathMode) {
+	callerPathMode = mode
+	resetCallerCache()
+}
+
+// ShowQualifiedFunctionName defines if the package-qualified function name of the caller should be shown
+// instead of the last segment of the name, e.g. github.com/org/app/db.(*Pool).Get instead of Get
+func ShowQualifiedFunctionName(show bool) {
+	showQualifiedFunctionName = show
+}
+
+func init() {
+	callerCache.Store(&callerCacheMap{})
+	helpers.Store(map[string]struct{}{})
+}
+
+// Helper marks the calling function as a logging helper function.
+// When the caller is resolved, the function is skipped like testing.T.Helper does for test helpers.
+func Helper() {
+	var pcs [1]uintptr
+	if runtime.Callers(2, pcs[:]) == 0 {
+		return
+	}
+
+	function := lookupCaller(pcs[0]).function
+	if _, ok := helpers.Load().(map[string]struct{})[function]; ok {
+		return
+	}
+
+	cacheMu.Lock()
+	defer cacheMu.Unlock()
+
+	current := helpers.Load().(map[string]struct{})
+	updated := make(map[string]struct{}, len(current)+1)
+	for name := range current {
+		updated[name] = struct{}{}
+	}
+	updated[function] = struct{}{}
+	helpers.Store(updated)
+}
+
+// resolveCaller returns the caller skip frames above resolveCaller, skipping functions marked with Helper
+func resolveCaller(skip int) (Caller, bool) {
+	_, entry := resolveFrame(skip + 1)
+	return entry.caller, entry.ok
+}
+
+// resolveFrame returns the program counter and the callerEntry of the first frame above skip
+// which is not marked with Helper
+func resolveFrame(skip int) (uintptr, callerEntry) {
+	var pcs [maxCallerSearch]uintptr
+	n := runtime.Callers(skip+1, pcs[:])
+
+	marked := helpers.Load().(map[string]struct{})
+	for _, pc := range pcs[:n] {
+		entry := lookupCaller(pc)
+		if _, helper := marked[entry.function]; !helper {
+			return pc, entry
+		}
+	}
+	return 0, callerEntry{}
+}
+
+// lookupCaller returns the cached callerEntry of the program counter and resolves it on a cache miss
+func lookupCaller(pc uintptr) callerEntry {
+	cache := callerCache.Load().(*callerCacheMap)
+	if entry, ok := cache.entries.Load(pc); ok {
+		return entry.(callerEntry)
+	}
+
+	frame, _ := runtime.CallersFrames([]uintptr{pc}).Next()
+	caller, ok := newCaller(frame)
+	entry := callerEntry{function: frame.Function, caller: caller, ok: ok}
+
+	// the entry is stored in the cache loaded before resolving, so it is discarded if the cache was reset meanwhile
+	if _, loaded := cache.entries.LoadOrStore(pc, entry); !loaded {
+		if atomic.AddInt64(&cache.size, 1) > maxCallerCacheSize {
+			resetCallerCache()
+		}
+	}
+	return entry
+}
+
+// resetCallerCache removes all resolved callers from the cache, e.g. after the path format has changed
+func resetCallerCache() {
+	callerCache.Store(&callerCacheMap{})
+}
+
+// getWorkingDir returns the working directory at the time of the first call.
+// If the working directory can not be determined an empty string is returned.
+func getWorkingDir() string {
+	workingDirOnce.Do(func() {
+		dir, err := os.Getwd()
+		if err == nil {
+			workingDir = dir
+		}
+	})
+	return workingDir
+}
+
+// newCaller converts a runtime.Frame to a Caller with the path formatted according to the CallerPathMode
+func newCaller(frame runtime.Frame) (Caller, bool) {
+	if frame.Function == "" {
+		return Caller{}, false
+	}
+
+	relpath := formatPath(frame)
+
+	if maxDepthOfCallerPath > 0 {
+		pathElements := strings.Split(relpath, string(os.PathSeparator))
+		length := len(pathElements)
+
+		start := length - maxDepthOfCallerPath
+		if start < 0 {
+			start = 0
+			relpath = ""
+		} else {
+			relpath = "..." + string(os.PathSeparator)
+		}
+		relpath += path.Join(pathElements[start:length]...)
+	}
+
+	// Get Name of the caller function
+	na := strings.Split(frame.Function, ".")
+
+	return Caller{
+		Path:         relpath,
+		FunctionName: na[len(na)-1],
+		LineNumber:   frame.Line,
+		Function:     frame.Function,
+		File:         frame.File,
+	}, true
+}
+
+// formatPath returns the file path of the frame according to the CallerPathMode.
+// If the path can not be formatted in the selected mode the absolute path is used.
+func formatPath(frame runtime.Frame) string {
+	switch callerPathMode {
+	case PathModule:
+		return modulePath(frame.Function, frame.File)
+	case PathPackage:
+		return path.Join(framePackage(frame.Function, frame.File), filepath.Base(frame.File))
+	case PathBase:
+		return filepath.Base(frame.File)
+	case PathAbsolute:
+		return frame.File
+	case PathTrimmed:
+		return trimGoPaths(frame.File)
+	}
+
+	if dir := getWorkingDir(); dir != "" {
+		if rel, err := filepath.Rel(dir, frame.File); err == nil {
+			return rel
+		}
+	}
+	return frame.File
+}
+
+// modulePath returns the path of the file relative to the root of the main module.
+// Files of other modules are returned with the import path of their package.
+func modulePath(function string, file string) string {
+	pkg, module := packagePath(function), getMainModule()
+	if pkg == "main" {
+		pkg, module = mainPackageOf(file)
+	}
+	if module != "" && (pkg == module || strings.HasPrefix(pkg, module+"/")) {
+		return path.Join(strings.TrimPrefix(strings.TrimPrefix(pkg, module), "/"), filepath.Base(file))
+	}
+	return path.Join(pkg, filepath.Base(file))
+}
+
+// framePackage returns the import path of the package of the function, see mainPackageOf
+func framePackage(function string, file string) string {
+	pkg := packagePath(function)
+	if pkg == "main" {
+		pkg, _ = mainPackageOf(file)
+	}
+	return pkg
+}
+
+// mainPackageOf returns the import path and the module of a file of the main package.
+// Functions of the main package are named main.*, so the import path is read from the build info
+// or derived from the file path and the go.mod file of the module, e.g. for go run main.go.
+func mainPackageOf(file string) (string, string) {
+	module := getMainModule()
+	if pkg := getMainPackage(); pkg != "" && pkg != "command-line-arguments" {
+		return pkg, module
+	}
+
+	dir := filepath.Dir(file)
+	root := findModuleRoot(dir)
+	if root.path == "" {
+		return "main", module
+	}
+	rel, err := filepath.Rel(root.dir, dir)
+	if err != nil {
+		return "main", module
+	}
+	return path.Join(root.path, filepath.ToSlash(rel)), root.path
+}
+
+// moduleRoot is the directory and the module path of a go.mod file
+type moduleRoot struct {
+	dir  string
+	path string
+}
+
+// findModuleRoot returns the nearest go.mod file starting at dir.
+// An empty moduleRoot is returned if there is none, e.g. because the binary runs without its sources.
+func findModuleRoot(dir string) moduleRoot {
+	if root, ok := moduleRoots.Load(dir); ok {
+		return root.(moduleRoot)
+	}
+
+	root := moduleRoot{}
+	for current := dir; ; current = filepath.Dir(current) {
+		if content, err := ioutil.ReadFile(filepath.Join(current, "go.mod")); err == nil {
+			root = moduleRoot{dir: current, path: modFilePath(content)}
+			break
+		}
+		if filepath.Dir(current) == current {
+			break
+		}
+	}
+	moduleRoots.Store(dir, root)
+	return root
+}
+
+// modFilePath returns the module path declared in the content of a go.mod file
+func modFilePath(content []byte) string {
+	for _, line := range strings.Split(string(content), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) >= 2 && fields[0] == "module" {
+			return strings.Trim(fields[1], `"`)
+		}
+	}
+	return ""
+}
+
+// packagePath returns the import path of the package of a package-qualified function name
+func packagePath(function string) string {
+	lastSlash := strings.LastIndex(function, "/")
+	dot := strings.Index(function[lastSlash+1:], ".")
+	if dot < 0 {
+		return function
+	}
+	return function[:lastSlash+1+dot]
+}
+
+// trimGoPaths removes the GOROOT and GOPATH prefixes of a file path
+func trimGoPaths(file string) string {
+	prefixes := []string{filepath.Join(runtime.GOROOT(), "src")}
+
+	gopath := os.Getenv("GOPATH")
+	if gopath == "" {
+		if home, err := os.UserHomeDir(); err == nil {
+			gopath = filepath.Join(home, "go")
+		}
+	}
+	for _, dir := range filepath.SplitList(gopath) {
+		prefixes = append(prefixes, filepath.Join(dir, "pkg", "mod"), filepath.Join(dir, "src"))
+	}
+
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(file, prefix+string(os.PathSeparator)) {
+			return strings.TrimPrefix(file, prefix+string(os.PathSeparator))
+		}
+	}
+	return file
+}
+
+// getMainModule returns the path of the main module read from the build info
+func getMainModule() string {
+	mainModuleOnce.Do(readMainModule)
+	return mainModule
+}
+
+// getMainPackage returns the import path of the main package read from the build info
+func getMainPackage() string {
+	mainModuleOnce.Do(readMainModule)
+	return mainPackage
+}
+
+func readMainModule() {
+	if info, ok := debug.ReadBuildInfo(); ok {
+		mainModule = info.Main.Path
+		mainPackage = info.Path
+	}
+}
@@ -0,0 +1,190 @@
+package log
+
+import (
+	"errors"
+	"io/ioutil"
+	log2 "log"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResolveCallerCache(t *testing.T) {
+	defer SetCallerMaxDepth(0)
+
+	caller, ok := resolveCaller(1)
+	assert.True(t, ok)
+	assert.Equal(t, "caller_test.go", caller.Path)
+	assert.Equal(t, "TestResolveCallerCache", caller.FunctionName)
+
+	SetCallerMaxDepth(1)
+	caller, _ = resolveCaller(1)
+	assert.Equal(t, "..."+string(os.PathSeparator)+"caller_test.go", caller.Path)
+}
+
+func TestResolveCallerWithoutFrames(t *testing.T) {
+	_, ok := resolveCaller(1000)
+	assert.False(t, ok)
+}
+
+func TestResolveCallerCacheHitDoesNotAllocate(t *testing.T) {
+	resolveCaller(1)
+	allocs := testing.AllocsPerRun(100, func() {
+		resolveCaller(1)
+	})
+	assert.Zero(t, allocs)
+}
+
+func TestCallerCacheIsBounded(t *testing.T) {
+	defer resetCallerCache()
+	resetCallerCache()
+
+	for pc := uintptr(1); pc <= maxCallerCacheSize+10; pc++ {
+		lookupCaller(pc)
+	}
+	assert.LessOrEqual(t, atomic.LoadInt64(&callerCache.Load().(*callerCacheMap).size), int64(maxCallerCacheSize))
+}
+
+func TestResetCallerCacheDiscardsResolvingEntries(t *testing.T) {
+	defer SetCallerPathMode(PathRelative)
+
+	// an entry resolved while the cache is reset is stored in the previous cache only
+	previous := callerCache.Load().(*callerCacheMap)
+	SetCallerPathMode(PathBase)
+	previous.entries.Store(uintptr(42), callerEntry{function: "stale"})
+
+	_, ok := callerCache.Load().(*callerCacheMap).entries.Load(uintptr(42))
+	assert.False(t, ok)
+}
+
+func TestCallerPathModes(t *testing.T) {
+	defer SetCallerPathMode(PathRelative)
+
+	_, file, _, _ := runtime.Caller(0)
+	tests := map[CallerPathMode]string{
+		PathRelative: "caller_test.go",
+		PathModule:   "caller_test.go",
+		PathPackage:  "github.com/chris-dot-exe/AwesomeLog/caller_test.go",
+		PathBase:     "caller_test.go",
+		PathAbsolute: file,
+	}
+
+	mainModuleOnce.Do(func() {})
+	defer func(module string) { mainModule = module }(mainModule)
+	mainModule = "github.com/chris-dot-exe/AwesomeLog"
+
+	for mode, expected := range tests {
+		SetCallerPathMode(mode)
+		caller, ok := resolveCaller(1)
+		assert.True(t, ok)
+		assert.Equal(t, expected, caller.Path)
+		assert.Equal(t, "github.com/chris-dot-exe/AwesomeLog.TestCallerPathModes", caller.Function)
+	}
+}
+
+func TestPackagePath(t *testing.T) {
+	assert.Equal(t, "github.com/org/app/db", packagePath("github.com/org/app/db.(*Pool).Get"))
+	assert.Equal(t, "github.com/org/app/db", packagePath("github.com/org/app/db.Open.func1"))
+	assert.Equal(t, "main", packagePath("main.main"))
+}
+
+// setMainPackage sets the main module and package as if they were read from the build info
+func setMainPackage(t *testing.T, module string, pkg string) {
+	t.Helper()
+
+	mainModuleOnce.Do(func() {})
+	prevModule, prevPackage := mainModule, mainPackage
+	t.Cleanup(func() {
+		mainModule, mainPackage = prevModule, prevPackage
+	})
+	mainModule, mainPackage = module, pkg
+}
+
+func TestModulePathOfMainPackage(t *testing.T) {
+	setMainPackage(t, "github.com/org/app", "github.com/org/app/cmd/probe")
+	assert.Equal(t, "cmd/probe/main.go", modulePath("main.main", "/build/cmd/probe/main.go"))
+	assert.Equal(t, "github.com/org/app/cmd/probe", framePackage("main.run.func1", "/build/cmd/probe/main.go"))
+	assert.Equal(t, "db/pool.go", modulePath("github.com/org/app/db.Open", "/build/db/pool.go"))
+
+	// go run main.go builds the package command-line-arguments without a main module, the go.mod file is read instead
+	root := t.TempDir()
+	file := filepath.Join(root, "cmd", "probe", "main.go")
+	assert.NoError(t, os.MkdirAll(filepath.Dir(file), 0o755))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(root, "go.mod"), []byte("module github.com/org/app\n\ngo 1.15\n"), 0o644))
+	setMainPackage(t, "", "command-line-arguments")
+	assert.Equal(t, "cmd/probe/main.go", modulePath("main.main", file))
+	assert.Equal(t, "github.com/org/app/cmd/probe", framePackage("main.main", file))
+
+	// without the sources the package stays main
+	assert.Equal(t, "main/main.go", modulePath("main.main", filepath.Join(t.TempDir(), "main.go")))
+}
+
+func TestTrimGoPaths(t *testing.T) {
+	gopath := filepath.Join(string(os.PathSeparator), "home", "user", "go")
+	defer os.Setenv("GOPATH", os.Getenv("GOPATH"))
+	_ = os.Setenv("GOPATH", gopath)
+
+	assert.Equal(t, filepath.Join("github.com", "lib", "pq@v1.10.0", "conn.go"),
+		trimGoPaths(filepath.Join(gopath, "pkg", "mod", "github.com", "lib", "pq@v1.10.0", "conn.go")))
+	assert.Equal(t, filepath.Join("net", "http", "server.go"),
+		trimGoPaths(filepath.Join(runtime.GOROOT(), "src", "net", "http", "server.go")))
+	assert.Equal(t, "/srv/app/main.go", trimGoPaths("/srv/app/main.go"))
+}
+
+func TestShowQualifiedFunctionName(t *testing.T) {
+	defer ShowQualifiedFunctionName(false)
+	defer SetLevelConfig(DefaultLevelConfig())
+	ShowQualifiedFunctionName(true)
+	ShowCaller(true)
+
+	message := Message{Level: INFO, Message: "msg", Caller: Caller{Path: "db.go", FunctionName: "Get", LineNumber: 3, Function: "github.com/org/app/db.(*Pool).Get"}}
+	assert.Contains(t, stringify(message), "github.com/org/app/db.(*Pool).Get")
+}
+
+// legacyGetCaller is the caller resolution used before the cache was introduced
+func legacyGetCaller(n int, fpcs []uintptr) (relpath string, name string, row int, err error) {
+	if n == 0 {
+		return "", "", -1, errors.New("MSG CALLER WAS NIL")
+	}
+
+	caller := runtime.FuncForPC(fpcs[0] - 1)
+	if caller == nil {
+		return "", "", -1, errors.New("MSG CALLER WAS NIL")
+	}
+
+	absPath, row := caller.FileLine(fpcs[0] - 1)
+	dir, err := os.Getwd()
+	if err != nil {
+		log2.Fatal(err)
+	}
+	relpath, err = filepath.Rel(dir, absPath)
+	if err != nil {
+		return "", "", -1, err
+	}
+
+	na := strings.Split(caller.Name(), ".")
+	name = na[len(na)-1]
+
+	return
+}
+
+func BenchmarkResolveCaller(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		resolveCaller(1)
+	}
+}
+
+func BenchmarkResolveCallerLegacy(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		fpcs := make([]uintptr, 1)
+		n := runtime.Callers(1, fpcs)
+		_, _, _, _ = legacyGetCaller(n, fpcs)
+	}
+}
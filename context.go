@@ -0,0 +1,93 @@
+package log
+
+import (
+	"context"
+	"sync"
+)
+
+type contextKey uint8
+
+const (
+	bufferKey contextKey = iota
+	levelKey
+	traceKey
+)
+
+// WithContext returns a Logger bound to ctx.
+// Messages are captured by the debug buffer of the context if one was added with WithDebugBuffer
+// and the LogLevel of the context set with WithLogLevel is used instead of the global LogLevel.
+// If the context contains a TraceContext the fields trace_id and span_id are added to every message.
+func WithContext(ctx context.Context) *Logger {
+	return New().WithContext(ctx)
+}
+
+// WithContext returns a copy of the Logger bound to ctx, see WithContext
+func (l *Logger) WithContext(ctx context.Context) *Logger {
+	clone := *l
+	clone.ctx = ctx
+	clone.buffer, _ = ctx.Value(bufferKey).(*debugBuffer)
+	clone.level, clone.hasLevel = ctx.Value(levelKey).(LogLevel)
+	if trace, ok := TraceContextFromContext(ctx); ok {
+		fields := make([]Field, 0, len(l.fields)+2)
+		for _, f := range l.fields {
+			if f.Key != "trace_id" && f.Key != "span_id" {
+				fields = append(fields, f)
+			}
+		}
+		clone.fields = append(fields, String("trace_id", trace.TraceID), String("span_id", trace.SpanID))
+	}
+	return &clone
+}
+
+// WithLogLevel returns a context with a LogLevel which raises the verbosity of all Loggers bound to the context,
+// e.g. to log DEBUG messages of a single request. Messages shown by the global LogLevel or the LogLevel of a named
+// Logger are always shown, so the LogLevel of the context can not be used to hide messages.
+func WithLogLevel(ctx context.Context, level LogLevel) context.Context {
+	return context.WithValue(ctx, levelKey, level)
+}
+
+// LogLevelFromContext returns the LogLevel set with WithLogLevel
+func LogLevelFromContext(ctx context.Context) (LogLevel, bool) {
+	level, ok := ctx.Value(levelKey).(LogLevel)
+	return level, ok
+}
+
+// WithDebugBuffer returns a context with a ring buffer, which captures the last size messages
+// of levels which are not shown by the LogLevel, e.g. DEBUG messages of a request.
+// When a message at or above the trigger level is logged by a Logger bound to the context,
+// the buffered messages are passed to the handlers of their level before it, marked with the field buffered=true.
+func WithDebugBuffer(ctx context.Context, size int, trigger LogLevel) context.Context {
+	return context.WithValue(ctx, bufferKey, &debugBuffer{
+		ring:    newRing(size),
+		trigger: trigger,
+	})
+}
+
+// debugBuffer is a ring buffer of messages which were not shown
+type debugBuffer struct {
+	trigger LogLevel
+
+	mu   sync.Mutex
+	ring ring
+}
+
+// add adds the message to the buffer and overwrites the oldest message if the buffer is full
+func (b *debugBuffer) add(message Message) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.ring.add(message)
+}
+
+// flush passes all buffered messages in order to the handlers of their level and empties the buffer
+func (b *debugBuffer) flush() {
+	b.mu.Lock()
+	messages := b.ring.messages()
+	b.ring.reset()
+	b.mu.Unlock()
+
+	for _, message := range messages {
+		message.setField(Bool("buffered", true))
+		dispatch(levelConfigFor(message.Name, message.Level).Handlers, message)
+	}
+}
@@ -0,0 +1,97 @@
+package log
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDebugBuffer(t *testing.T) {
+	messages := captureMessages(t)
+	SetLogLevel(INFO)
+
+	ctx := WithDebugBuffer(context.Background(), 3, ERROR)
+	logger := WithContext(ctx)
+
+	for i := 0; i < 5; i++ {
+		logger.Printf(DEBUG, "step %d", i)
+	}
+	logger.Println(VERBOSE, "details")
+	logger.Println(INFO, "shown")
+	assert.Len(t, *messages, 1)
+
+	logger.Println(ERROR, "failed")
+
+	var texts []string
+	for _, message := range *messages {
+		texts = append(texts, message.Message)
+	}
+	assert.Equal(t, []string{"shown\n", "step 3", "step 4", "details\n", "failed\n"}, texts)
+
+	buffered, _ := (*messages)[1].Field("buffered")
+	assert.Equal(t, true, buffered)
+	assert.Equal(t, "context_test.go", (*messages)[1].Caller.Path)
+	_, ok := (*messages)[4].Field("buffered")
+	assert.False(t, ok)
+
+	logger.Println(ERROR, "failed again")
+	assert.Len(t, *messages, 6)
+}
+
+func TestDebugBufferScopes(t *testing.T) {
+	messages := captureMessages(t)
+	SetLogLevel(INFO)
+
+	first := WithContext(WithDebugBuffer(context.Background(), 10, ERROR))
+	second := WithContext(WithDebugBuffer(context.Background(), 10, ERROR))
+
+	first.Println(DEBUG, "first request")
+	second.Println(DEBUG, "second request")
+	Println(DEBUG, "no scope")
+	second.Println(CRITICAL, "second failed")
+
+	assert.Len(t, *messages, 2)
+	assert.Equal(t, "second request\n", (*messages)[0].Message)
+	assert.Equal(t, "second failed\n", (*messages)[1].Message)
+}
+
+func TestContextLogLevel(t *testing.T) {
+	messages := captureMessages(t)
+	t.Cleanup(ResetNamedLevels)
+	SetLogLevel(WARN)
+	SetNamedLogLevel("db", ERROR)
+
+	ctx := WithLogLevel(context.Background(), DEBUG)
+	level, ok := LogLevelFromContext(ctx)
+	assert.True(t, ok)
+	assert.Equal(t, DEBUG, level)
+
+	WithContext(ctx).Println(DEBUG, "request debug")
+	WithContext(ctx).Named("db").Println(DEBUG, "db debug")
+	Println(DEBUG, "other request")
+	Named("db").Println(WARN, "db warn")
+
+	assert.Len(t, *messages, 2)
+	assert.Equal(t, "request debug\n", (*messages)[0].Message)
+	assert.Equal(t, "db debug\n", (*messages)[1].Message)
+}
+
+func TestContextLogLevelDoesNotLowerVerbosity(t *testing.T) {
+	messages := captureMessages(t)
+	t.Cleanup(ResetNamedLevels)
+	SetLogLevel(INFO)
+	SetNamedLogLevel("db", DEBUG)
+
+	for _, level := range []LogLevel{ERROR, NONE} {
+		ctx := WithLogLevel(context.Background(), level)
+		WithContext(ctx).Println(INFO, "global info")
+		WithContext(ctx).Named("db").Println(DEBUG, "db debug")
+		WithContext(ctx).Println(DEBUG, "hidden")
+	}
+
+	assert.Len(t, *messages, 4)
+	for _, message := range *messages {
+		assert.NotEqual(t, "hidden\n", message.Message)
+	}
+}
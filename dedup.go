@@ -0,0 +1,128 @@
+package log
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Dedup returns a Middleware which collapses consecutive identical messages with the same level, Logger name,
+// caller, text and fields.
+// Repetitions within window after the first message are dropped and summarized with a message
+// "last message repeated N times", which carries the count in the field "repeated".
+// The summary is passed on when a different message arrives or the window has passed.
+// Messages formatted by the Sprint functions are passed through unchanged and do not count as repetitions.
+func Dedup(window time.Duration) Middleware {
+	d := &deduplicator{window: window}
+	return func(next Handler) Handler {
+		return func(message Message) {
+			d.handle(message, next)
+		}
+	}
+}
+
+type deduplicator struct {
+	window time.Duration
+
+	mu       sync.Mutex
+	last     Message
+	next     Handler
+	repeated int
+	start    time.Time
+	timer    *time.Timer
+}
+
+func (d *deduplicator) handle(message Message, next Handler) {
+	// messages formatted by the Sprint functions are never dispatched, so they must not change the state
+	if message.formatOnly {
+		next(message)
+		return
+	}
+
+	d.mu.Lock()
+	if d.next != nil && isRepetition(d.last, message) && message.Time.Sub(d.start) < d.window {
+		d.repeated++
+		d.next = next
+		if d.timer == nil {
+			d.timer = time.AfterFunc(d.window-message.Time.Sub(d.start), d.expire)
+		}
+		d.mu.Unlock()
+		return
+	}
+
+	summary, summaryNext, ok := d.flush()
+	d.last = message
+	d.next = next
+	d.start = message.Time
+	d.mu.Unlock()
+
+	// the handlers are called without holding the lock, so they may log themselves
+	if ok {
+		summaryNext(summary)
+	}
+	next(message)
+}
+
+// expire passes the summary on after the window has passed
+func (d *deduplicator) expire() {
+	d.mu.Lock()
+	summary, next, ok := d.flush()
+	d.next = nil
+	d.mu.Unlock()
+
+	if ok {
+		next(summary)
+	}
+}
+
+// flush returns the summary of the repetitions of the last message and the Handler to pass it to.
+// The caller must hold the lock and pass the summary on after releasing it.
+func (d *deduplicator) flush() (Message, Handler, bool) {
+	if d.timer != nil {
+		d.timer.Stop()
+		d.timer = nil
+	}
+	if d.repeated == 0 {
+		return Message{}, nil, false
+	}
+
+	summary := Message{
+		Time:    time.Now(),
+		Level:   d.last.Level,
+		Name:    d.last.Name,
+		Caller:  d.last.Caller,
+		Message: "last message repeated " + strconv.Itoa(d.repeated) + " times",
+		Fields:  d.last.Fields,
+
+		handlers: d.last.handlers,
+	}
+	if strings.HasSuffix(d.last.Message, "\n") {
+		summary.Message += "\n"
+	}
+	summary.setField(Int("repeated", d.repeated))
+
+	d.repeated = 0
+	return summary, d.next, true
+}
+
+// isRepetition returns if the message has the same level, name, caller, text and fields as the last message
+func isRepetition(last Message, message Message) bool {
+	return last.Level == message.Level && last.Name == message.Name && last.Caller == message.Caller &&
+		last.Message == message.Message && sameFields(last.Fields, message.Fields)
+}
+
+// sameFields returns if both messages have the same fields with equal values
+func sameFields(a []Field, b []Field) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i].Key != b[i].Key || a[i].kind != b[i].kind || a[i].integer != b[i].integer ||
+			a[i].nanos != b[i].nanos || a[i].str != b[i].str || !reflect.DeepEqual(a[i].value, b[i].value) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,144 @@
+package log
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDedup(t *testing.T) {
+	messages := captureMessages(t)
+	AddMiddleware(Dedup(time.Hour))
+
+	for i := 0; i < 5; i++ {
+		Println(WARN, "connection refused")
+	}
+	Println(WARN, "connected")
+
+	assert.Len(t, *messages, 3)
+	assert.Equal(t, "connection refused\n", (*messages)[0].Message)
+
+	summary := (*messages)[1]
+	assert.Equal(t, "last message repeated 4 times\n", summary.Message)
+	assert.Equal(t, WARN, summary.Level)
+	repeated, _ := summary.Field("repeated")
+	assert.Equal(t, 4, repeated)
+
+	assert.Equal(t, "connected\n", (*messages)[2].Message)
+}
+
+func TestDedupDifferentCallerOrLevel(t *testing.T) {
+	messages := captureMessages(t)
+	AddMiddleware(Dedup(time.Hour))
+
+	for _, level := range []LogLevel{WARN, WARN, ERROR} {
+		Println(level, "retry")
+	}
+	Println(ERROR, "retry")
+
+	assert.Len(t, *messages, 4)
+	assert.Equal(t, "last message repeated 1 times\n", (*messages)[1].Message)
+	assert.Equal(t, ERROR, (*messages)[2].Level)
+	assert.Equal(t, "retry\n", (*messages)[3].Message)
+}
+
+func TestDedupDifferentFieldsOrName(t *testing.T) {
+	messages := captureMessages(t)
+	AddMiddleware(Dedup(time.Hour))
+
+	for i := 0; i < 3; i++ {
+		Log(INFO, "request", Int("id", i))
+	}
+	for _, name := range []string{"db", "http"} {
+		Named(name).Log(INFO, "request", Int("id", 2))
+	}
+
+	assert.Len(t, *messages, 5)
+	for i, message := range (*messages)[:3] {
+		id, _ := message.Field("id")
+		assert.Equal(t, i, id)
+	}
+	assert.Equal(t, "db", (*messages)[3].Name)
+	assert.Equal(t, "http", (*messages)[4].Name)
+}
+
+func TestDedupWindow(t *testing.T) {
+	captureMessages(t)
+
+	mu := sync.Mutex{}
+	messages := &[]Message{}
+	config.Info.SetHandlers([]Handler{func(message Message) {
+		mu.Lock()
+		defer mu.Unlock()
+		*messages = append(*messages, message)
+	}})
+	AddMiddleware(Dedup(20 * time.Millisecond))
+
+	for i := 0; i < 3; i++ {
+		Println(INFO, "tick")
+	}
+
+	assert.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(*messages) == 2
+	}, time.Second, 5*time.Millisecond)
+
+	mu.Lock()
+	assert.Equal(t, "last message repeated 2 times\n", (*messages)[1].Message)
+	mu.Unlock()
+
+	Println(INFO, "tick")
+	mu.Lock()
+	assert.Len(t, *messages, 3)
+	mu.Unlock()
+}
+
+func TestDedupIgnoresSprint(t *testing.T) {
+	messages := captureMessages(t)
+	AddMiddleware(Dedup(time.Hour))
+	ShowTimestamp(false)
+	defer ShowTimestamp(showTimestamp)
+
+	for i := 0; i < 3; i++ {
+		assert.Contains(t, Sprintln(WARN, "same"), "same")
+	}
+	for i := 0; i < 3; i++ {
+		Println(WARN, "same")
+	}
+	Println(WARN, "other")
+
+	assert.Len(t, *messages, 3)
+	assert.Equal(t, "last message repeated 2 times\n", (*messages)[1].Message)
+}
+
+func TestDedupHandlerMayLog(t *testing.T) {
+	messages := captureMessages(t)
+	AddMiddleware(Dedup(time.Hour))
+
+	logged := false
+	config.Error.SetHandlers([]Handler{func(message Message) {
+		*messages = append(*messages, message)
+		if !logged {
+			logged = true
+			Println(INFO, "handler logged")
+		}
+	}})
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		Println(ERROR, "failed")
+		Println(ERROR, "failed")
+		Println(WARN, "next")
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("deadlock in Dedup")
+	}
+	assert.Len(t, *messages, 4)
+}
@@ -0,0 +1,306 @@
+package log
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// DumpConfig represents the configuration of Dump and Sdump
+type DumpConfig struct {
+	// MaxDepth limits how deep nested values are walked. 0 means unlimited.
+	MaxDepth int
+	// MaxLength limits the number of shown elements of slices, arrays and maps
+	// as well as the length of strings. 0 means unlimited.
+	MaxLength int
+	// Indent is used to indent nested values
+	Indent string
+}
+
+var dumpConfig = DefaultDumpConfig()
+
+// DefaultDumpConfig returns the default config for Dump and Sdump
+func DefaultDumpConfig() DumpConfig {
+	return DumpConfig{
+		MaxDepth:  10,
+		MaxLength: 100,
+		Indent:    "  ",
+	}
+}
+
+// SetDumpConfig sets the config for Dump and Sdump
+func SetDumpConfig(cfg DumpConfig) {
+	dumpConfig = cfg
+}
+
+// Dump logs a message at the defined LogLevel with a detailed representation of the given values.
+// In contrast to PrettyPrint, Dump shows types, unexported fields and pointer addresses,
+// detects cycles and works with every type including channels and functions.
+// Struct fields tagged with `log:"-"` are skipped and fields tagged with `log:"redact"` are masked.
+func Dump(params ...interface{}) {
+	std.Dump(params...)
+}
+
+// Sdump returns the log message of Dump as string
+func Sdump(params ...interface{}) string {
+	return std.Sdump(params...)
+}
+
+// Dump logs a message at the defined LogLevel with a detailed representation of the given values, see Dump
+func (l *Logger) Dump(params ...interface{}) {
+	level, _, params := getLogLevel(false, params...)
+	if l.skip(level, callPrintln, "", params, nil) {
+		return
+	}
+	params = evaluateLazy(params)
+	l.println(level, sdump(dumpConfig, params...))
+}
+
+// Sdump returns the log message of Dump as string
+func (l *Logger) Sdump(params ...interface{}) string {
+	level, _, params := getLogLevel(false, params...)
+	if !l.shows(level) {
+		return ""
+	}
+	params = evaluateLazy(params)
+	return l.sprintln(level, sdump(dumpConfig, params...))
+}
+
+// sdump renders every value in its own line
+func sdump(cfg DumpConfig, values ...interface{}) string {
+	d := dumper{cfg: cfg, visited: map[visitKey]bool{}}
+	for i, value := range values {
+		if i > 0 {
+			d.sb.WriteString("\n")
+		}
+		d.dump(reflect.ValueOf(value), 0)
+	}
+	return d.sb.String()
+}
+
+type dumper struct {
+	cfg     DumpConfig
+	sb      strings.Builder
+	visited map[visitKey]bool
+}
+
+func (d *dumper) newline(depth int) {
+	d.sb.WriteString("\n")
+	d.sb.WriteString(strings.Repeat(d.cfg.Indent, depth))
+}
+
+func (d *dumper) dump(v reflect.Value, depth int) {
+	if !v.IsValid() {
+		d.sb.WriteString("<nil>")
+		return
+	}
+
+	d.sb.WriteString("(")
+	d.sb.WriteString(v.Type().String())
+	d.sb.WriteString(") ")
+	d.dumpValue(v, depth)
+}
+
+func (d *dumper) dumpValue(v reflect.Value, depth int) {
+	if d.cfg.MaxDepth > 0 && depth > d.cfg.MaxDepth {
+		d.sb.WriteString("<max depth reached>")
+		return
+	}
+
+	switch v.Kind() {
+	case reflect.Interface:
+		if v.IsNil() {
+			d.sb.WriteString("<nil>")
+			return
+		}
+		d.dump(v.Elem(), depth)
+	case reflect.Ptr:
+		if v.IsNil() {
+			d.sb.WriteString("<nil>")
+			return
+		}
+		key := visitKey{ptr: v.Pointer()}
+		d.sb.WriteString("0x" + strconv.FormatUint(uint64(key.ptr), 16))
+		if d.visited[key] {
+			d.sb.WriteString(" <cycle>")
+			return
+		}
+		d.visited[key] = true
+		defer delete(d.visited, key)
+		d.sb.WriteString(" -> ")
+		d.dump(v.Elem(), depth)
+	case reflect.Struct:
+		d.dumpStruct(v, depth)
+	case reflect.Map:
+		d.dumpMap(v, depth)
+	case reflect.Slice:
+		if v.IsNil() {
+			d.sb.WriteString("<nil>")
+			return
+		}
+		d.sb.WriteString(fmt.Sprintf("(len=%d cap=%d) ", v.Len(), v.Cap()))
+		// empty slices can not contain a cycle, their key could collide with a pointer
+		if v.Len() > 0 {
+			key := visitKey{ptr: v.Pointer(), len: v.Len()}
+			if d.visited[key] {
+				d.sb.WriteString("<cycle>")
+				return
+			}
+			d.visited[key] = true
+			defer delete(d.visited, key)
+		}
+		d.dumpList(v, depth)
+	case reflect.Array:
+		d.sb.WriteString(fmt.Sprintf("(len=%d) ", v.Len()))
+		d.dumpList(v, depth)
+	case reflect.String:
+		str := v.String()
+		if d.cfg.MaxLength > 0 && len(str) > d.cfg.MaxLength {
+			d.sb.WriteString(strconv.Quote(str[:d.cfg.MaxLength]))
+			d.sb.WriteString(fmt.Sprintf("... (%d more bytes)", len(str)-d.cfg.MaxLength))
+			return
+		}
+		d.sb.WriteString(strconv.Quote(str))
+	case reflect.Bool:
+		d.sb.WriteString(strconv.FormatBool(v.Bool()))
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		d.sb.WriteString(strconv.FormatInt(v.Int(), 10))
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		d.sb.WriteString(strconv.FormatUint(v.Uint(), 10))
+	case reflect.Float32, reflect.Float64:
+		d.sb.WriteString(strconv.FormatFloat(v.Float(), 'g', -1, 64))
+	case reflect.Complex64, reflect.Complex128:
+		d.sb.WriteString(strconv.FormatComplex(v.Complex(), 'g', -1, 128))
+	case reflect.Chan, reflect.Func, reflect.UnsafePointer:
+		if v.IsNil() {
+			d.sb.WriteString("<nil>")
+			return
+		}
+		d.sb.WriteString("0x" + strconv.FormatUint(uint64(v.Pointer()), 16))
+	default:
+		d.sb.WriteString("<unsupported>")
+	}
+}
+
+func (d *dumper) dumpStruct(v reflect.Value, depth int) {
+	t := v.Type()
+	if t.NumField() == 0 {
+		d.sb.WriteString("{}")
+		return
+	}
+
+	d.sb.WriteString("{")
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := parseLogTag(field)
+		if tag.skip {
+			continue
+		}
+
+		d.newline(depth + 1)
+		d.sb.WriteString(field.Name)
+		d.sb.WriteString(": ")
+		if tag.redact {
+			d.sb.WriteString("(")
+			d.sb.WriteString(field.Type.String())
+			d.sb.WriteString(") ")
+			d.sb.WriteString(maskTagged(v.Field(i)))
+		} else {
+			d.dump(v.Field(i), depth+1)
+		}
+		d.sb.WriteString(",")
+	}
+	d.newline(depth)
+	d.sb.WriteString("}")
+}
+
+func (d *dumper) dumpList(v reflect.Value, depth int) {
+	if v.Len() == 0 {
+		d.sb.WriteString("{}")
+		return
+	}
+
+	length := v.Len()
+	if d.cfg.MaxLength > 0 && length > d.cfg.MaxLength {
+		length = d.cfg.MaxLength
+	}
+
+	d.sb.WriteString("{")
+	for i := 0; i < length; i++ {
+		d.newline(depth + 1)
+		d.dump(v.Index(i), depth+1)
+		d.sb.WriteString(",")
+	}
+	if length < v.Len() {
+		d.newline(depth + 1)
+		d.sb.WriteString(fmt.Sprintf("... (%d more)", v.Len()-length))
+	}
+	d.newline(depth)
+	d.sb.WriteString("}")
+}
+
+func (d *dumper) dumpMap(v reflect.Value, depth int) {
+	if v.IsNil() {
+		d.sb.WriteString("<nil>")
+		return
+	}
+
+	key := visitKey{ptr: v.Pointer()}
+	if d.visited[key] {
+		d.sb.WriteString("<cycle>")
+		return
+	}
+	d.visited[key] = true
+	defer delete(d.visited, key)
+
+	d.sb.WriteString(fmt.Sprintf("(len=%d) ", v.Len()))
+	if v.Len() == 0 {
+		d.sb.WriteString("{}")
+		return
+	}
+
+	keys := v.MapKeys()
+	sortValues(keys)
+
+	length := len(keys)
+	if d.cfg.MaxLength > 0 && length > d.cfg.MaxLength {
+		length = d.cfg.MaxLength
+	}
+
+	d.sb.WriteString("{")
+	for _, key := range keys[:length] {
+		d.newline(depth + 1)
+		d.dump(key, depth+1)
+		d.sb.WriteString(": ")
+		d.dump(v.MapIndex(key), depth+1)
+		d.sb.WriteString(",")
+	}
+	if length < len(keys) {
+		d.newline(depth + 1)
+		d.sb.WriteString(fmt.Sprintf("... (%d more)", len(keys)-length))
+	}
+	d.newline(depth)
+	d.sb.WriteString("}")
+}
+
+// sortValues sorts map keys by their natural order, other kinds are sorted by their formatted value
+func sortValues(values []reflect.Value) {
+	sort.Slice(values, func(i, j int) bool {
+		a, b := values[i], values[j]
+		switch a.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			return a.Int() < b.Int()
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+			return a.Uint() < b.Uint()
+		case reflect.Float32, reflect.Float64:
+			return a.Float() < b.Float()
+		case reflect.String:
+			return a.String() < b.String()
+		case reflect.Bool:
+			return !a.Bool() && b.Bool()
+		}
+		return fmt.Sprintf("%v", a) < fmt.Sprintf("%v", b)
+	})
+}
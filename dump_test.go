@@ -0,0 +1,111 @@
+package log
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type node struct {
+	Name     string
+	value    int
+	next     *node
+	children map[string]int
+	notify   chan bool
+	callback func()
+}
+
+func TestSdumpStruct(t *testing.T) {
+	n := node{
+		Name:     "root",
+		value:    7,
+		children: map[string]int{"b": 2, "a": 1},
+	}
+
+	expected := "(log.node) {\n" +
+		"  Name: (string) \"root\",\n" +
+		"  value: (int) 7,\n" +
+		"  next: (*log.node) <nil>,\n" +
+		"  children: (map[string]int) (len=2) {\n" +
+		"    (string) \"a\": (int) 1,\n" +
+		"    (string) \"b\": (int) 2,\n" +
+		"  },\n" +
+		"  notify: (chan bool) <nil>,\n" +
+		"  callback: (func()) <nil>,\n" +
+		"}"
+
+	assert.Equal(t, expected, sdump(DefaultDumpConfig(), n))
+}
+
+func TestSdumpCycle(t *testing.T) {
+	n := &node{Name: "loop", notify: make(chan bool), callback: func() {}}
+	n.next = n
+
+	output := sdump(DefaultDumpConfig(), n)
+	assert.Contains(t, output, "next: (*log.node) 0x")
+	assert.Contains(t, output, "<cycle>")
+	assert.Regexp(t, `notify: \(chan bool\) 0x[0-9a-f]+`, output)
+	assert.Regexp(t, `callback: \(func\(\)\) 0x[0-9a-f]+`, output)
+}
+
+func TestSdumpSliceCycle(t *testing.T) {
+	s := []interface{}{nil}
+	s[0] = s
+
+	cfg := DefaultDumpConfig()
+	cfg.MaxDepth = 0
+	assert.Contains(t, sdump(cfg, s), "<cycle>")
+}
+
+func TestSdumpLimits(t *testing.T) {
+	cfg := DumpConfig{MaxDepth: 1, MaxLength: 2, Indent: " "}
+
+	assert.Equal(t, "([]int) (len=3 cap=3) {\n (int) 1,\n (int) 2,\n ... (1 more)\n}", sdump(cfg, []int{1, 2, 3}))
+	assert.Equal(t, "(string) \"ab\"... (2 more bytes)", sdump(cfg, "abcd"))
+	assert.Equal(t, "([][][]int) (len=1 cap=1) {\n ([][]int) (len=1 cap=1) {\n  ([]int) <max depth reached>,\n },\n}", sdump(cfg, [][][]int{{{1}}}))
+}
+
+func TestPrettyPrintFallsBackToDump(t *testing.T) {
+	messages := captureMessages(t)
+
+	PrettyPrint(INFO, make(chan int))
+	Dump(DEBUG, 42)
+
+	assert.Len(t, *messages, 2)
+	assert.True(t, strings.HasPrefix((*messages)[0].Message, "(chan int) 0x"))
+	assert.Equal(t, "(int) 42\n", (*messages)[1].Message)
+}
+
+type dumpCredentials struct {
+	User     string
+	Password string `log:"redact"`
+	Token    string `log:"-"`
+	secret   string `log:"redact"`
+	Done     chan bool
+}
+
+func TestSdumpHonorsLogTags(t *testing.T) {
+	defer SetRedactor(redactor)
+	credentials := dumpCredentials{User: "alice", Password: "hunter2", Token: "tok-secret", secret: "s3cr3t", Done: make(chan bool)}
+
+	for _, r := range []*Redactor{nil, DefaultRedactor()} {
+		SetRedactor(r)
+		output := Sdump(INFO, credentials)
+		assert.Contains(t, output, `User: (string) "alice"`)
+		assert.Contains(t, output, "Password: (string) [REDACTED]")
+		assert.Contains(t, output, "secret: (string) [REDACTED]")
+		assert.NotContains(t, output, "hunter2")
+		assert.NotContains(t, output, "s3cr3t")
+		assert.NotContains(t, output, "Token")
+		assert.NotContains(t, output, "tok-secret")
+	}
+
+	// PrettyPrint falls back to Dump because of the chan field
+	messages := captureMessages(t)
+	PrettyPrint(INFO, credentials)
+	assert.Len(t, *messages, 1)
+	assert.Contains(t, (*messages)[0].Message, "Password: (string) [REDACTED]")
+	assert.NotContains(t, (*messages)[0].Message, "hunter2")
+	assert.NotContains(t, (*messages)[0].Message, "tok-secret")
+}
@@ -0,0 +1,207 @@
+package log
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// maxErrorDepth limits how deep error chains are unwrapped
+const maxErrorDepth = 32
+
+// ErrorInfo contains the structured data of an error passed as parameter to a log function
+type ErrorInfo struct {
+	Message string
+	Type    string
+	// Stack is set if the error carries a stack trace, e.g. errors of github.com/pkg/errors
+	Stack []Frame
+	// Causes contains the wrapped errors. It contains more than one cause for errors
+	// implementing Unwrap() []error.
+	Causes []ErrorInfo
+}
+
+// Root returns the innermost cause of the error. For joined errors the first cause is followed.
+func (e ErrorInfo) Root() ErrorInfo {
+	for len(e.Causes) > 0 {
+		e = e.Causes[0]
+	}
+	return e
+}
+
+// newErrorInfo unwraps the error chain of err
+func newErrorInfo(err error) ErrorInfo {
+	return errorInfo(err, 0)
+}
+
+func errorInfo(err error, depth int) (info ErrorInfo) {
+	// methods of the error may panic, e.g. for typed nil pointers, which must not crash the program
+	defer func() {
+		if r := recover(); r != nil {
+			info = ErrorInfo{Message: errorMessage(err), Type: fmt.Sprintf("%T", err)}
+		}
+	}()
+
+	info = ErrorInfo{
+		Message: err.Error(),
+		Type:    fmt.Sprintf("%T", err),
+		Stack:   errorStack(err),
+	}
+
+	if depth >= maxErrorDepth {
+		return info
+	}
+
+	switch e := err.(type) {
+	case interface{ Unwrap() []error }:
+		for _, cause := range e.Unwrap() {
+			if !isNil(cause) {
+				info.Causes = append(info.Causes, errorInfo(cause, depth+1))
+			}
+		}
+	case interface{ Unwrap() error }:
+		if cause := e.Unwrap(); !isNil(cause) {
+			info.Causes = []ErrorInfo{errorInfo(cause, depth+1)}
+		}
+	}
+	return info
+}
+
+// errorStack returns the stack trace carried by the error.
+// Supported are errors with a method Callers() []uintptr and errors with a method StackTrace()
+// returning a slice of program counters like github.com/pkg/errors.
+func errorStack(err error) []Frame {
+	if e, ok := err.(interface{ Callers() []uintptr }); ok {
+		return framesOf(e.Callers())
+	}
+
+	method := reflect.ValueOf(err).MethodByName("StackTrace")
+	if !method.IsValid() || method.Type().NumIn() != 0 || method.Type().NumOut() != 1 {
+		return nil
+	}
+
+	out := method.Type().Out(0)
+	if out.Kind() != reflect.Slice || out.Elem().Kind() != reflect.Uintptr {
+		return nil
+	}
+
+	trace := method.Call(nil)[0]
+	pcs := make([]uintptr, trace.Len())
+	for i := range pcs {
+		pcs[i] = uintptr(trace.Index(i).Uint())
+	}
+	return framesOf(pcs)
+}
+
+// collectErrors returns the ErrorInfo of every error in params, including arguments of formatted messages
+func collectErrors(params []interface{}) []ErrorInfo {
+	var infos []ErrorInfo
+	for _, param := range params {
+		switch p := param.(type) {
+		case error:
+			if !isNil(p) {
+				infos = append(infos, newErrorInfo(p))
+			}
+		case formatted:
+			infos = append(infos, collectErrors(p.args)...)
+		}
+	}
+	return infos
+}
+
+// appendErrors appends the cause tree of errors which wrap other errors or carry a stack trace.
+// A trailing newline of the message is preserved.
+func appendErrors(text string, errs []ErrorInfo) string {
+	var sb strings.Builder
+	for _, info := range errs {
+		if len(info.Causes) == 0 && len(info.Stack) == 0 {
+			continue
+		}
+		writeErrorTree(&sb, info, 1, "")
+	}
+	if sb.Len() == 0 {
+		return text
+	}
+
+	trimmed := strings.TrimSuffix(text, "\n")
+	return trimmed + sb.String() + text[len(trimmed):]
+}
+
+func writeErrorTree(sb *strings.Builder, info ErrorInfo, depth int, prefix string) {
+	indent := strings.Repeat("  ", depth)
+
+	sb.WriteString("\n")
+	sb.WriteString(indent)
+	sb.WriteString(prefix)
+	sb.WriteString(info.Type)
+	sb.WriteString(": ")
+	sb.WriteString(info.Message)
+
+	for _, frame := range info.Stack {
+		sb.WriteString("\n")
+		sb.WriteString(indent)
+		sb.WriteString("    at ")
+		sb.WriteString(frame.Function)
+		sb.WriteString(" (")
+		sb.WriteString(frame.File)
+		sb.WriteString(":")
+		sb.WriteString(strconv.Itoa(frame.Line))
+		sb.WriteString(")")
+	}
+
+	for _, cause := range info.Causes {
+		writeErrorTree(sb, cause, depth+1, "caused by ")
+	}
+}
+
+// errorsToPretty converts the error infos to a pretty tree
+func errorsToPretty(errs []ErrorInfo) []interface{} {
+	list := make([]interface{}, len(errs))
+	for i, info := range errs {
+		object := prettyObject{
+			{Key: "message", Value: info.Message},
+			{Key: "type", Value: info.Type},
+		}
+		if len(info.Stack) > 0 {
+			object = append(object, prettyEntry{Key: "stack", Value: framesToPretty(info.Stack)})
+		}
+		if len(info.Causes) > 0 {
+			object = append(object, prettyEntry{Key: "causes", Value: errorsToPretty(info.Causes)})
+		}
+		list[i] = object
+	}
+	return list
+}
+
+// isNil returns true if err is nil or a typed nil pointer, map, slice, func or chan
+func isNil(err error) bool {
+	if err == nil {
+		return true
+	}
+	v := reflect.ValueOf(err)
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Map, reflect.Slice, reflect.Func, reflect.Chan, reflect.Interface:
+		return v.IsNil()
+	}
+	return false
+}
+
+// errorMessage returns the message of err like fmt does, see callMethod
+func errorMessage(err error) string {
+	return callMethod(err, "Error", err.Error)
+}
+
+// callMethod returns the result of the Error or String method of value. Like fmt, a panic of the method
+// is rendered as <nil> for nil pointers and reported in the result otherwise instead of crashing the program.
+func callMethod(value interface{}, name string, method func() string) (result string) {
+	defer func() {
+		if r := recover(); r != nil {
+			if v := reflect.ValueOf(value); v.Kind() == reflect.Ptr && v.IsNil() {
+				result = "<nil>"
+				return
+			}
+			result = fmt.Sprintf("%%!v(PANIC=%s method: %v)", name, r)
+		}
+	}()
+	return method()
+}
@@ -0,0 +1,128 @@
+package log
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// stackError carries a stack trace like the errors of github.com/pkg/errors
+type stackError struct {
+	msg string
+	pcs []uintptr
+}
+
+type stackTrace []uintptr
+
+func (e *stackError) Error() string { return e.msg }
+
+func (e *stackError) StackTrace() stackTrace { return e.pcs }
+
+func newStackError(msg string) error {
+	pcs := make([]uintptr, 8)
+	n := runtime.Callers(2, pcs)
+	return &stackError{msg: msg, pcs: pcs[:n]}
+}
+
+// joinedError wraps multiple errors like errors.Join
+type joinedError []error
+
+func (e joinedError) Error() string   { return "joined" }
+func (e joinedError) Unwrap() []error { return e }
+
+func TestErrorInfo(t *testing.T) {
+	_, err := os.Open("/does/not/exist")
+	wrapped := fmt.Errorf("load config: %w", err)
+
+	info := newErrorInfo(wrapped)
+	assert.Equal(t, "load config: open /does/not/exist: no such file or directory", info.Message)
+	assert.Equal(t, "*fmt.wrapError", info.Type)
+	assert.Len(t, info.Causes, 1)
+	assert.Equal(t, "*fs.PathError", info.Causes[0].Type)
+	assert.Equal(t, "syscall.Errno", info.Root().Type)
+	assert.Equal(t, "no such file or directory", info.Root().Message)
+}
+
+func TestErrorInfoJoinedAndStack(t *testing.T) {
+	info := newErrorInfo(joinedError{errors.New("first"), nil, newStackError("second")})
+
+	assert.Len(t, info.Causes, 2)
+	assert.Empty(t, info.Causes[0].Stack)
+	assert.NotEmpty(t, info.Causes[1].Stack)
+	assert.Equal(t, "github.com/chris-dot-exe/AwesomeLog.TestErrorInfoJoinedAndStack", info.Causes[1].Stack[0].Function)
+}
+
+func TestErrorsInMessages(t *testing.T) {
+	messages := captureMessages(t)
+
+	base := errors.New("connection refused")
+	Println(ERROR, "request failed:", fmt.Errorf("fetch user: %w", base))
+	Printf(WARN, "retry in %ds: %v", 5, base)
+	Println(INFO, "no error")
+
+	assert.Len(t, *messages, 3)
+	assert.Len(t, (*messages)[0].Errors, 1)
+	assert.Equal(t, "connection refused", (*messages)[0].Errors[0].Root().Message)
+	assert.Equal(t, "retry in 5s: connection refused", (*messages)[1].Message)
+	assert.Len(t, (*messages)[1].Errors, 1)
+	assert.Empty(t, (*messages)[2].Errors)
+}
+
+// nilError dereferences its receiver in Error like most error types
+type nilError struct {
+	msg string
+}
+
+func (e *nilError) Error() string { return e.msg }
+
+func (e *nilError) String() string { return e.msg }
+
+// panicError panics in Error even though it is not nil
+type panicError struct{}
+
+func (panicError) Error() string { panic("boom") }
+
+func TestTypedNilErrorsInMessages(t *testing.T) {
+	messages := captureMessages(t)
+
+	var typedNil *nilError
+	Println(ERROR, "failed:", typedNil)
+	Println(ERROR, "failed:", panicError{})
+	Println(ERROR, "wrapped:", fmt.Errorf("fetch: %w", error(typedNil)))
+	Log(ERROR, "field", Err(typedNil), Stringer("stringer", typedNil))
+
+	assert.Len(t, *messages, 4)
+	assert.Equal(t, "failed:<nil>\n", (*messages)[0].Message)
+	assert.Empty(t, (*messages)[0].Errors)
+	assert.Equal(t, "%!v(PANIC=Error method: boom)", (*messages)[1].Errors[0].Message)
+	assert.Len(t, (*messages)[2].Errors, 1)
+	assert.Empty(t, (*messages)[2].Errors[0].Causes)
+	assert.Contains(t, stringify((*messages)[3]), "error=<nil> stringer=<nil>")
+	assert.Equal(t, "<nil>", Err(typedNil).encodeJSON())
+}
+
+func TestAppendErrors(t *testing.T) {
+	info := newErrorInfo(fmt.Errorf("fetch user: %w", errors.New("connection refused")))
+
+	expected := "request failed\n" +
+		"  *fmt.wrapError: fetch user: connection refused\n" +
+		"    caused by *errors.errorString: connection refused\n"
+	assert.Equal(t, expected, appendErrors("request failed\n", []ErrorInfo{info}))
+
+	plain := newErrorInfo(errors.New("plain"))
+	assert.Equal(t, "text", appendErrors("text", []ErrorInfo{plain}))
+
+	withStack := appendErrors("text", []ErrorInfo{newErrorInfo(newStackError("traced"))})
+	assert.True(t, strings.Contains(withStack, "    at github.com/chris-dot-exe/AwesomeLog.TestAppendErrors ("))
+}
+
+func TestFormatJSONErrors(t *testing.T) {
+	msg := Message{Errors: []ErrorInfo{newErrorInfo(fmt.Errorf("outer: %w", errors.New("inner")))}}
+
+	assert.Contains(t, string(FormatJSON(msg)), `"errors":[{"message":"outer: inner","type":"*fmt.wrapError","causes":[{"message":"inner","type":"*errors.errorString"}]}]`)
+}
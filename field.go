@@ -0,0 +1,183 @@
+package log
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// fieldKind defines how the value of a Field is stored
+type fieldKind uint8
+
+const (
+	anyField fieldKind = iota
+	stringField
+	intField
+	int64Field
+	floatField
+	boolField
+	durationField
+	timeField
+	errorField
+	stringerField
+)
+
+// String returns a Field with a string value
+func String(key string, value string) Field {
+	return Field{Key: key, kind: stringField, str: value}
+}
+
+// Int returns a Field with an int value
+func Int(key string, value int) Field {
+	return Field{Key: key, kind: intField, integer: int64(value)}
+}
+
+// Int64 returns a Field with an int64 value
+func Int64(key string, value int64) Field {
+	return Field{Key: key, kind: int64Field, integer: value}
+}
+
+// Float returns a Field with a float64 value
+func Float(key string, value float64) Field {
+	return Field{Key: key, kind: floatField, integer: int64(math.Float64bits(value))}
+}
+
+// Bool returns a Field with a bool value
+func Bool(key string, value bool) Field {
+	var integer int64
+	if value {
+		integer = 1
+	}
+	return Field{Key: key, kind: boolField, integer: integer}
+}
+
+// Duration returns a Field with a time.Duration value
+func Duration(key string, value time.Duration) Field {
+	return Field{Key: key, kind: durationField, integer: int64(value)}
+}
+
+// Time returns a Field with a time.Time value
+func Time(key string, value time.Time) Field {
+	return Field{Key: key, kind: timeField, integer: value.Unix(), nanos: int32(value.Nanosecond()), value: value.Location()}
+}
+
+// Err returns a Field with the key "error" and the given error as value
+func Err(err error) Field {
+	return Field{Key: "error", kind: errorField, value: err}
+}
+
+// Stringer returns a Field whose value is the result of the String method of value.
+// String is only called if the message is formatted.
+func Stringer(key string, value fmt.Stringer) Field {
+	return Field{Key: key, kind: stringerField, value: value}
+}
+
+// Any returns a Field with an arbitrary value.
+// Structs, maps and slices are rendered as JSON honoring the log struct tags.
+func Any(key string, value interface{}) Field {
+	return Field{Key: key, value: value}
+}
+
+// Value returns the value of the Field
+func (f Field) Value() interface{} {
+	switch f.kind {
+	case stringField:
+		return f.str
+	case intField:
+		return int(f.integer)
+	case int64Field:
+		return f.integer
+	case floatField:
+		return math.Float64frombits(uint64(f.integer))
+	case boolField:
+		return f.integer == 1
+	case durationField:
+		return time.Duration(f.integer)
+	case timeField:
+		return f.time()
+	}
+	return f.value
+}
+
+func (f Field) time() time.Time {
+	t := time.Unix(f.integer, int64(f.nanos))
+	if location, ok := f.value.(*time.Location); ok && location != nil {
+		t = t.In(location)
+	}
+	return t
+}
+
+// encodeText returns the value of the Field formatted for the text output
+func (f Field) encodeText() string {
+	switch f.kind {
+	case stringField:
+		return quoteFieldValue(f.str)
+	case intField, int64Field:
+		return strconv.FormatInt(f.integer, 10)
+	case floatField:
+		return strconv.FormatFloat(math.Float64frombits(uint64(f.integer)), 'g', -1, 64)
+	case boolField:
+		return strconv.FormatBool(f.integer == 1)
+	case durationField:
+		return time.Duration(f.integer).String()
+	case timeField:
+		return f.time().Format(time.RFC3339Nano)
+	case errorField:
+		if f.value == nil {
+			return "<nil>"
+		}
+		return quoteFieldValue(errorMessage(f.value.(error)))
+	case stringerField:
+		if f.value == nil {
+			return "<nil>"
+		}
+		stringer := f.value.(fmt.Stringer)
+		return quoteFieldValue(callMethod(stringer, "String", stringer.String))
+	}
+	return formatFieldValue(f.value)
+}
+
+// encodeJSON returns the value of the Field as pretty tree for the JSON output
+func (f Field) encodeJSON() interface{} {
+	switch f.kind {
+	case stringField:
+		return f.str
+	case intField, int64Field:
+		return json.Number(strconv.FormatInt(f.integer, 10))
+	case floatField:
+		value := math.Float64frombits(uint64(f.integer))
+		if math.IsInf(value, 0) || math.IsNaN(value) {
+			return strconv.FormatFloat(value, 'g', -1, 64)
+		}
+		return json.Number(strconv.FormatFloat(value, 'g', -1, 64))
+	case boolField:
+		return f.integer == 1
+	case durationField:
+		return time.Duration(f.integer).String()
+	case timeField:
+		return f.time().Format(time.RFC3339Nano)
+	case errorField:
+		if f.value == nil {
+			return nil
+		}
+		return errorMessage(f.value.(error))
+	case stringerField:
+		if f.value == nil {
+			return nil
+		}
+		stringer := f.value.(fmt.Stringer)
+		return callMethod(stringer, "String", stringer.String)
+	}
+	return jsonValue(f.value)
+}
+
+// quoteFieldValue quotes the value if it is empty or contains characters which would break key=value parsing
+func quoteFieldValue(str string) string {
+	if str == "" || strings.ContainsAny(str, " =\"\t\n") {
+		return strconv.Quote(str)
+	}
+	return str
+}
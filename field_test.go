@@ -0,0 +1,169 @@
+package log
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type version struct{}
+
+func (version) String() string {
+	return "v1.2.3"
+}
+
+func TestTypedFields(t *testing.T) {
+	at := time.Date(2022, 2, 15, 22, 46, 51, 0, time.UTC)
+
+	tests := []struct {
+		field Field
+		value interface{}
+		text  string
+		json  string
+	}{
+		{String("user", "jane doe"), "jane doe", `user="jane doe"`, `"user":"jane doe"`},
+		{Int("count", 3), 3, "count=3", `"count":3`},
+		{Int64("size", -42), int64(-42), "size=-42", `"size":-42`},
+		{Float("ratio", 0.5), 0.5, "ratio=0.5", `"ratio":0.5`},
+		{Bool("ok", true), true, "ok=true", `"ok":true`},
+		{Duration("took", 1500*time.Millisecond), 1500 * time.Millisecond, "took=1.5s", `"took":"1.5s"`},
+		{Time("at", at), at, "at=2022-02-15T22:46:51Z", `"at":"2022-02-15T22:46:51Z"`},
+		{Err(errors.New("not found")), errors.New("not found"), `error="not found"`, `"error":"not found"`},
+		{Stringer("version", version{}), version{}, "version=v1.2.3", `"version":"v1.2.3"`},
+		{Any("tags", []string{"a", "b"}), []string{"a", "b"}, `tags=["a","b"]`, `"tags":["a","b"]`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.field.Key, func(t *testing.T) {
+			assert.Equal(t, test.value, test.field.Value())
+			assert.Equal(t, "msg "+test.text+"\n", appendFields("msg\n", []Field{test.field}))
+			assert.Contains(t, string(FormatJSON(Message{Fields: []Field{test.field}})), test.json)
+		})
+	}
+}
+
+func TestLogWithFields(t *testing.T) {
+	messages := captureMessages(t)
+
+	logger := With(String("service", "db"))
+	logger.Log(INFO, "connected", Int("pool", 4))
+	logger.Println(WARN, "slow query", Duration("took", time.Second))
+	Log(DEBUG, "plain")
+
+	assert.Len(t, *messages, 3)
+
+	first := (*messages)[0]
+	assert.Equal(t, "connected\n", first.Message)
+	assert.Equal(t, []Field{String("service", "db"), Int("pool", 4)}, first.Fields)
+
+	second := (*messages)[1]
+	assert.Equal(t, "slow query\n", second.Message)
+	took, _ := second.Field("took")
+	assert.Equal(t, time.Second, took)
+	assert.Len(t, second.Fields, 2)
+
+	assert.Empty(t, (*messages)[2].Fields)
+}
+
+func TestRedactTypedFields(t *testing.T) {
+	r := DefaultRedactor()
+
+	message := r.redactMessage(Message{Fields: []Field{
+		String("password", "hunter2"),
+		Int("token", 1234),
+		String("auth", "Bearer abc.def"),
+		Err(errors.New("login of jane@example.com failed")),
+	}})
+
+	for _, f := range message.Fields {
+		assert.False(t, strings.Contains(f.encodeText(), "hunter2") || strings.Contains(f.encodeText(), "1234") ||
+			strings.Contains(f.encodeText(), "abc.def") || strings.Contains(f.encodeText(), "jane@"), f.encodeText())
+	}
+}
+
+func TestTimeFieldOutsideUnixNanoRange(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skip(err)
+	}
+
+	tests := []time.Time{
+		{},
+		time.Date(1200, 1, 2, 3, 4, 5, 6, time.UTC),
+		time.Date(2500, 6, 7, 8, 9, 10, 11, berlin),
+	}
+
+	for _, at := range tests {
+		f := Time("at", at)
+		assert.True(t, at.Equal(f.Value().(time.Time)))
+		assert.Equal(t, "at="+at.Format(time.RFC3339Nano), strings.TrimSpace(appendFields("", []Field{f})))
+		assert.Equal(t, at.Format(time.RFC3339Nano), f.encodeJSON())
+	}
+}
+
+func TestRedactKeepsUnchangedFields(t *testing.T) {
+	r := DefaultRedactor()
+
+	fields := []Field{
+		String("user", "jane"),
+		Err(errors.New("not found")),
+		Stringer("version", version{}),
+	}
+	message := r.redactMessage(Message{Fields: fields})
+	assert.Equal(t, fields, message.Fields)
+
+	message = r.redactMessage(Message{Fields: []Field{Err(errors.New("login of jane@example.com failed"))}})
+	assert.Equal(t, stringField, message.Fields[0].kind)
+}
+
+func TestLogWithFieldsDisabledDoesNotAllocate(t *testing.T) {
+	defer SetLogLevel(VERBOSE)
+	SetLogLevel(INFO)
+
+	now := time.Now()
+	allocs := testing.AllocsPerRun(100, func() {
+		Log(DEBUG, "disabled", String("key", "value"), Int("count", 1), Duration("took", time.Second), Time("at", now))
+	})
+	if allocs != 0 {
+		t.Errorf("expected 0 allocations for disabled levels, got %v", allocs)
+	}
+}
+
+func BenchmarkLogFieldsDisabled(b *testing.B) {
+	defer SetLogLevel(VERBOSE)
+	SetLogLevel(INFO)
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		Log(DEBUG, "disabled", String("key", "value"), Int("count", i), Duration("took", time.Second))
+	}
+}
+
+func BenchmarkLogFields(b *testing.B) {
+	prevConfig := config
+	defer SetLevelConfig(prevConfig)
+	cfg := DefaultLevelConfig()
+	cfg.Info.SetHandlers([]Handler{func(message Message) {}})
+	SetLevelConfig(cfg)
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		Log(INFO, "enabled", String("key", "value"), Int("count", i), Duration("took", time.Second))
+	}
+}
+
+func BenchmarkPrintlnAnyFields(b *testing.B) {
+	prevConfig := config
+	defer SetLevelConfig(prevConfig)
+	cfg := DefaultLevelConfig()
+	cfg.Info.SetHandlers([]Handler{func(message Message) {}})
+	SetLevelConfig(cfg)
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		Println(INFO, "enabled", Any("key", "value"), Any("count", i), Any("took", time.Second))
+	}
+}
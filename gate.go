@@ -0,0 +1,120 @@
+package log
+
+import (
+	"sync"
+	"time"
+)
+
+// gateKind defines when a gate passes messages
+type gateKind uint8
+
+const (
+	gateFirstN gateKind = iota
+	gateEveryN
+	gateEvery
+)
+
+// gate passes messages of a call site depending on how often or how long ago the call site logged
+type gate struct {
+	kind     gateKind
+	n        int64
+	interval time.Duration
+}
+
+type gateKey struct {
+	pc uintptr
+	gate
+}
+
+type gateState struct {
+	count int64
+	last  time.Time
+}
+
+var gateMu sync.Mutex
+var gateStates = map[gateKey]*gateState{}
+
+// ResetGates forgets how often and when every call site logged through LogOnce, LogFirstN, LogEveryN and LogEvery,
+// e.g. to run tests logging through gates repeatedly:
+//
+//	t.Cleanup(log.ResetGates)
+func ResetGates() {
+	gateMu.Lock()
+	defer gateMu.Unlock()
+
+	gateStates = map[gateKey]*gateState{}
+}
+
+// LogOnce returns a Logger which logs only the first message of every call site
+func LogOnce() *Logger {
+	return New().LogOnce()
+}
+
+// LogFirstN returns a Logger which logs only the first n messages of every call site
+func LogFirstN(n int) *Logger {
+	return New().LogFirstN(n)
+}
+
+// LogEveryN returns a Logger which logs the first and thereafter every nth message of every call site
+func LogEveryN(n int) *Logger {
+	return New().LogEveryN(n)
+}
+
+// LogEvery returns a Logger which logs a message of every call site at most once per interval
+func LogEvery(interval time.Duration) *Logger {
+	return New().LogEvery(interval)
+}
+
+// LogOnce returns a copy of the Logger which logs only the first message of every call site
+func (l *Logger) LogOnce() *Logger {
+	return l.LogFirstN(1)
+}
+
+// LogFirstN returns a copy of the Logger which logs only the first n messages of every call site
+func (l *Logger) LogFirstN(n int) *Logger {
+	return l.withGate(gate{kind: gateFirstN, n: int64(n)})
+}
+
+// LogEveryN returns a copy of the Logger which logs the first and thereafter every nth message of every call site
+func (l *Logger) LogEveryN(n int) *Logger {
+	return l.withGate(gate{kind: gateEveryN, n: int64(n)})
+}
+
+// LogEvery returns a copy of the Logger which logs a message of every call site at most once per interval
+func (l *Logger) LogEvery(interval time.Duration) *Logger {
+	return l.withGate(gate{kind: gateEvery, interval: interval})
+}
+
+func (l *Logger) withGate(g gate) *Logger {
+	clone := *l
+	clone.gate = &g
+	return &clone
+}
+
+// pass returns if the message of the call site pc passes the gate
+func (g *gate) pass(pc uintptr) bool {
+	gateMu.Lock()
+	defer gateMu.Unlock()
+
+	key := gateKey{pc: pc, gate: *g}
+	state, ok := gateStates[key]
+	if !ok {
+		state = &gateState{}
+		gateStates[key] = state
+	}
+	state.count++
+
+	switch g.kind {
+	case gateFirstN:
+		return state.count <= g.n
+	case gateEveryN:
+		return g.n <= 1 || state.count%g.n == 1
+	case gateEvery:
+		now := time.Now()
+		if state.count > 1 && now.Sub(state.last) < g.interval {
+			return false
+		}
+		state.last = now
+	}
+	return true
+}
@@ -0,0 +1,79 @@
+package log
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLogGates(t *testing.T) {
+	messages := captureMessages(t)
+	t.Cleanup(ResetGates)
+
+	for i := 0; i < 10; i++ {
+		LogOnce().Println(INFO, "once", i)
+		LogFirstN(3).Printf(WARN, "first %d", i)
+		LogEveryN(4).Println(ERROR, "every", i)
+		LogEvery(time.Hour).PrettyPrint(DEBUG, i)
+	}
+
+	var texts []string
+	for _, message := range *messages {
+		texts = append(texts, message.Message)
+	}
+	assert.Equal(t, []string{
+		"once0\n", "first 0", "every0\n", "[\n  0\n]\n",
+		"first 1",
+		"first 2",
+		"every4\n",
+		"every8\n",
+	}, texts)
+}
+
+func TestLogGatesPerCallSite(t *testing.T) {
+	messages := captureMessages(t)
+	t.Cleanup(ResetGates)
+	logger := New().With(String("component", "db"))
+
+	for i := 0; i < 3; i++ {
+		logger.LogOnce().Log(INFO, "first site")
+		logger.LogOnce().Log(INFO, "second site")
+	}
+
+	assert.Len(t, *messages, 2)
+	assert.Len(t, (*messages)[0].Fields, 1)
+}
+
+func TestLogGatesFormat(t *testing.T) {
+	captureMessages(t)
+	t.Cleanup(ResetGates)
+
+	var outputs []string
+	for i := 0; i < 3; i++ {
+		outputs = append(outputs, LogOnce().Sprint(INFO, "once"))
+	}
+	assert.NotEmpty(t, outputs[0])
+	assert.Empty(t, outputs[1])
+	assert.Empty(t, outputs[2])
+}
+
+// logOnceHelper is a wrapper logging through a gate, its callers are gated separately
+func logOnceHelper(msg string) {
+	Helper()
+	LogOnce().Println(INFO, msg)
+}
+
+func TestLogGatesSkipHelpers(t *testing.T) {
+	messages := captureMessages(t)
+	t.Cleanup(ResetGates)
+
+	for i := 0; i < 3; i++ {
+		logOnceHelper("first caller")
+		logOnceHelper("second caller")
+	}
+
+	assert.Len(t, *messages, 2)
+	assert.Equal(t, "first caller\n", (*messages)[0].Message)
+	assert.Equal(t, "second caller\n", (*messages)[1].Message)
+}
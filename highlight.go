@@ -0,0 +1,110 @@
+package log
+
+import "strings"
+
+// Theme defines the ANSI colors used to highlight the output of PrettyPrint and SprettyPrint
+type Theme struct {
+	Key    string
+	String string
+	Number string
+	Bool   string
+	Null   string
+}
+
+// prettyText marks the message text as JSON rendered by PrettyPrint
+type prettyText string
+
+var highlightPretty = false
+var prettyTheme = DefaultTheme()
+
+// DefaultTheme returns the default Theme for highlighted PrettyPrint output
+func DefaultTheme() Theme {
+	return Theme{
+		Key:    ANSI_CYAN,
+		String: ANSI_GREEN,
+		Number: ANSI_YELLOW,
+		Bool:   ANSI_PURPLE,
+		Null:   ANSI_RED,
+	}
+}
+
+// HighlightPrettyPrint defines if the output of PrettyPrint and SprettyPrint should be syntax highlighted.
+// Highlighting follows the same rules as colored level tags, see ShowColors and ShowColorsInLogs.
+func HighlightPrettyPrint(highlight bool) {
+	highlightPretty = highlight
+}
+
+// SetTheme sets the Theme used to highlight the output of PrettyPrint and SprettyPrint
+func SetTheme(theme Theme) {
+	prettyTheme = theme
+}
+
+// highlightJSON colors keys, strings, numbers, booleans and null of the JSON text.
+// Invalid JSON, e.g. masked by a Redactor, is passed through unchanged.
+func highlightJSON(text string, theme Theme) string {
+	var sb strings.Builder
+	sb.Grow(len(text) * 2)
+
+	colored := func(color string, token string) {
+		if color == "" {
+			sb.WriteString(token)
+			return
+		}
+		sb.WriteString(color)
+		sb.WriteString(token)
+		sb.WriteString(ANSI_RESET)
+	}
+
+	for i := 0; i < len(text); {
+		c := text[i]
+		switch {
+		case c == '"':
+			end := i + 1
+			for end < len(text) && text[end] != '"' {
+				if text[end] == '\\' {
+					end++
+				}
+				end++
+			}
+			if end < len(text) {
+				end++
+			}
+
+			next := end
+			for next < len(text) && (text[next] == ' ' || text[next] == '\t') {
+				next++
+			}
+			if next < len(text) && text[next] == ':' {
+				colored(theme.Key, text[i:end])
+			} else {
+				colored(theme.String, text[i:end])
+			}
+			i = end
+		case c == '-' || (c >= '0' && c <= '9'):
+			end := i + 1
+			for end < len(text) && strings.IndexByte("0123456789.eE+-", text[end]) >= 0 {
+				end++
+			}
+			colored(theme.Number, text[i:end])
+			i = end
+		case strings.HasPrefix(text[i:], "true"):
+			colored(theme.Bool, "true")
+			i += 4
+		case strings.HasPrefix(text[i:], "false"):
+			colored(theme.Bool, "false")
+			i += 5
+		case strings.HasPrefix(text[i:], "null"):
+			colored(theme.Null, "null")
+			i += 4
+		default:
+			end := i + 1
+			for end < len(text) && strings.IndexByte("\"-0123456789tfn", text[end]) < 0 {
+				end++
+			}
+			sb.WriteString(text[i:end])
+			i = end
+		}
+	}
+
+	return sb.String()
+}
@@ -0,0 +1,43 @@
+package log
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHighlightJSON(t *testing.T) {
+	theme := Theme{Key: "<k>", String: "<s>", Number: "<n>", Bool: "<b>", Null: "<0>"}
+
+	input := "{\n  \"name\": \"a \\\"quoted\\\" value\",\n  \"count\": -1.5e3,\n  \"ok\": true,\n  \"next\": null,\n  \"list\": [false, \"x\"]\n}"
+	expected := "{\n  <k>\"name\"" + ANSI_RESET + ": <s>\"a \\\"quoted\\\" value\"" + ANSI_RESET + ",\n" +
+		"  <k>\"count\"" + ANSI_RESET + ": <n>-1.5e3" + ANSI_RESET + ",\n" +
+		"  <k>\"ok\"" + ANSI_RESET + ": <b>true" + ANSI_RESET + ",\n" +
+		"  <k>\"next\"" + ANSI_RESET + ": <0>null" + ANSI_RESET + ",\n" +
+		"  <k>\"list\"" + ANSI_RESET + ": [<b>false" + ANSI_RESET + ", <s>\"x\"" + ANSI_RESET + "]\n}"
+
+	assert.Equal(t, expected, highlightJSON(input, theme))
+}
+
+func TestHighlightPrettyPrint(t *testing.T) {
+	prevShowColors, prevColorsInLogs, prevShowTimestamp := showColors, colorsInLogs, showTimestamp
+	defer func() {
+		showColors, colorsInLogs, showTimestamp = prevShowColors, prevColorsInLogs, prevShowTimestamp
+		HighlightPrettyPrint(false)
+		SetTheme(DefaultTheme())
+	}()
+
+	SetLogLevel(INFO)
+	ShowTimestamp(false)
+	ShowColors(true)
+	ShowColorsInLogs(true)
+	HighlightPrettyPrint(true)
+	SetTheme(Theme{Number: ANSI_YELLOW})
+
+	tag := ANSI_BLUE_BACKGROUND + ANSI_WHITE + "[INFO]" + ANSI_RESET
+	assert.Equal(t, tag+" [\n  "+ANSI_YELLOW+"42"+ANSI_RESET+"\n]\n", SprettyPrint(INFO, 42))
+	assert.Equal(t, tag+" 42\n", Sprintln(INFO, 42))
+
+	ShowColors(false)
+	assert.Equal(t, "[INFO] [\n  42\n]\n", SprettyPrint(INFO, 42))
+}
@@ -0,0 +1,285 @@
+package log
+
+import (
+	"bufio"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// LevelOverrideHeader is the header checked by LevelOverride for a signed LogLevel token
+	LevelOverrideHeader = "X-Log-Level"
+	// LevelOverrideQuery is the query parameter checked by LevelOverride for a signed LogLevel token
+	LevelOverrideQuery = "log_level"
+)
+
+// SignLevelOverride returns a token for LevelOverride which sets the LogLevel of requests until expires.
+// The token has the format LEVEL.EXPIRES.SIGNATURE, where the signature is a HMAC-SHA256 of LEVEL.EXPIRES.
+func SignLevelOverride(secret []byte, level LogLevel, expires time.Time) string {
+	payload := level.String() + "." + strconv.FormatInt(expires.Unix(), 10)
+	return payload + "." + signature(secret, payload)
+}
+
+// LevelOverride returns a HTTP middleware which sets the LogLevel of the request context with WithLogLevel
+// if the request contains a valid token created by SignLevelOverride in the header LevelOverrideHeader
+// or the query parameter LevelOverrideQuery. Requests without a valid token are passed on unchanged.
+// LevelOverride panics if the secret is empty, because everyone could create valid tokens.
+func LevelOverride(secret []byte) func(next http.Handler) http.Handler {
+	if len(secret) == 0 {
+		panic("log: LevelOverride requires a non-empty secret")
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			token := r.Header.Get(LevelOverrideHeader)
+			if token == "" {
+				token = r.URL.Query().Get(LevelOverrideQuery)
+			}
+
+			if level, ok := verifyLevelOverride(secret, token, time.Now()); ok {
+				r = r.WithContext(WithLogLevel(r.Context(), level))
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// verifyLevelOverride returns the LogLevel of the token if the signature is valid and the token is not expired
+func verifyLevelOverride(secret []byte, token string, now time.Time) (LogLevel, bool) {
+	if len(secret) == 0 {
+		return 0, false
+	}
+
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return 0, false
+	}
+
+	payload := parts[0] + "." + parts[1]
+	if !hmac.Equal([]byte(parts[2]), []byte(signature(secret, payload))) {
+		return 0, false
+	}
+
+	expires, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil || now.Unix() > expires {
+		return 0, false
+	}
+
+	lvl, ok := level[parts[0]]
+	return lvl, ok
+}
+
+func signature(secret []byte, payload string) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(payload))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// AccessLogFormat defines how requests are logged by AccessLog
+type AccessLogFormat uint
+
+const (
+	// AccessLogFields logs the request as message "METHOD PATH" with the request details as fields
+	AccessLogFields AccessLogFormat = iota
+	// AccessLogCombined logs the request in the Apache/NCSA combined log format
+	AccessLogCombined
+)
+
+// AccessLogConfig is the configuration of the AccessLog middleware
+type AccessLogConfig struct {
+	Format AccessLogFormat
+	// SkipPaths contains paths which are not logged, e.g. /healthz
+	SkipPaths []string
+	// RequestIDHeader is the header containing the request ID, default is X-Request-ID
+	RequestIDHeader string
+	// Logger is used to log the requests, default is a new Logger.
+	// The Logger is bound to the context of the request.
+	Logger *Logger
+}
+
+// AccessLog returns a HTTP middleware which logs every request with method, path, status, bytes, duration,
+// remote address, user agent and request ID. Requests are logged at ERROR for 5xx status codes,
+// at WARN for 4xx status codes and at INFO otherwise. The caller is not shown and tokens of LevelOverride
+// in the query are removed from the logged URI.
+func AccessLog(cfg AccessLogConfig) func(next http.Handler) http.Handler {
+	if cfg.RequestIDHeader == "" {
+		cfg.RequestIDHeader = "X-Request-ID"
+	}
+	if cfg.Logger == nil {
+		cfg.Logger = New()
+	}
+
+	skip := make(map[string]bool, len(cfg.SkipPaths))
+	for _, path := range cfg.SkipPaths {
+		skip[path] = true
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if skip[r.URL.Path] {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			start := time.Now()
+			rw := &accessLogWriter{ResponseWriter: w, status: http.StatusOK}
+			next.ServeHTTP(rw, r)
+
+			logAccess(cfg, r, rw, start)
+		})
+	}
+}
+
+func logAccess(cfg AccessLogConfig, r *http.Request, rw *accessLogWriter, start time.Time) {
+	// the caller would always be logAccess, so it is not shown
+	logger := cfg.Logger.WithContext(r.Context())
+	logger.hideCaller = true
+	level := accessLogLevel(rw.status)
+
+	if cfg.Format == AccessLogCombined {
+		logger.Log(level, combinedLogLine(r, rw, start))
+		return
+	}
+
+	logger.Log(level, r.Method+" "+r.URL.Path,
+		String("method", r.Method),
+		String("path", r.URL.Path),
+		Int("status", rw.status),
+		Int64("bytes", rw.bytes),
+		Duration("duration", time.Since(start)),
+		String("remote", r.RemoteAddr),
+		String("user_agent", r.UserAgent()),
+		String("request_id", requestID(cfg, r, rw)),
+	)
+}
+
+// accessLogLevel returns the LogLevel of a request by its status class
+func accessLogLevel(status int) LogLevel {
+	switch {
+	case status >= 500:
+		return ERROR
+	case status >= 400:
+		return WARN
+	}
+	return INFO
+}
+
+// requestID returns the request ID of the request or the response
+func requestID(cfg AccessLogConfig, r *http.Request, rw *accessLogWriter) string {
+	if id := r.Header.Get(cfg.RequestIDHeader); id != "" {
+		return id
+	}
+	return rw.Header().Get(cfg.RequestIDHeader)
+}
+
+// combinedLogLine formats the request in the Apache/NCSA combined log format
+func combinedLogLine(r *http.Request, rw *accessLogWriter, start time.Time) string {
+	host := r.RemoteAddr
+	if h, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		host = h
+	}
+
+	user := "-"
+	if r.URL.User != nil && r.URL.User.Username() != "" {
+		user = r.URL.User.Username()
+	} else if name, _, ok := r.BasicAuth(); ok && name != "" {
+		user = name
+	}
+
+	size := "-"
+	if rw.bytes > 0 {
+		size = strconv.FormatInt(rw.bytes, 10)
+	}
+
+	return fmt.Sprintf("%s - %s [%s] \"%s %s %s\" %d %s %q %q",
+		host, user, start.Format("02/Jan/2006:15:04:05 -0700"),
+		r.Method, withoutLevelOverride(r.RequestURI), r.Proto, rw.status, size, withoutLevelOverride(r.Referer()), r.UserAgent())
+}
+
+// withoutLevelOverride removes the LevelOverrideQuery parameter from the URI,
+// so signed tokens can not be reused by everyone with access to the logs
+func withoutLevelOverride(uri string) string {
+	i := strings.IndexByte(uri, '?')
+	if i < 0 {
+		return uri
+	}
+
+	query, fragment := uri[i+1:], ""
+	if j := strings.IndexByte(query, '#'); j >= 0 {
+		query, fragment = query[:j], query[j:]
+	}
+
+	params := strings.Split(query, "&")
+	kept := params[:0]
+	for _, param := range params {
+		key := param
+		if j := strings.IndexByte(param, '='); j >= 0 {
+			key = param[:j]
+		}
+		if unescaped, err := url.QueryUnescape(key); err == nil && unescaped == LevelOverrideQuery {
+			continue
+		}
+		kept = append(kept, param)
+	}
+	if len(kept) == len(params) {
+		return uri
+	}
+
+	uri = uri[:i]
+	if len(kept) > 0 {
+		uri += "?" + strings.Join(kept, "&")
+	}
+	return uri + fragment
+}
+
+// accessLogWriter records the status code and the number of bytes written
+type accessLogWriter struct {
+	http.ResponseWriter
+	status      int
+	bytes       int64
+	wroteHeader bool
+}
+
+func (w *accessLogWriter) WriteHeader(status int) {
+	if !w.wroteHeader {
+		w.status = status
+		w.wroteHeader = true
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *accessLogWriter) Write(b []byte) (int, error) {
+	w.wroteHeader = true
+	n, err := w.ResponseWriter.Write(b)
+	w.bytes += int64(n)
+	return n, err
+}
+
+// Flush implements http.Flusher if the wrapped ResponseWriter supports it
+func (w *accessLogWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Hijack implements http.Hijacker if the wrapped ResponseWriter supports it
+func (w *accessLogWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, fmt.Errorf("the ResponseWriter does not implement http.Hijacker")
+	}
+	return h.Hijack()
+}
+
+// Unwrap returns the wrapped ResponseWriter
+func (w *accessLogWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
@@ -0,0 +1,182 @@
+package log
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLevelOverride(t *testing.T) {
+	messages := captureMessages(t)
+	SetLogLevel(INFO)
+
+	secret := []byte("secret")
+	handler := LevelOverride(secret)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		WithContext(r.Context()).Println(DEBUG, r.URL.Path)
+	}))
+
+	valid := SignLevelOverride(secret, DEBUG, time.Now().Add(time.Hour))
+	expired := SignLevelOverride(secret, DEBUG, time.Now().Add(-time.Hour))
+	forged := SignLevelOverride([]byte("other"), DEBUG, time.Now().Add(time.Hour))
+
+	requests := map[string]*http.Request{
+		"/header":  httptest.NewRequest(http.MethodGet, "/header", nil),
+		"/query":   httptest.NewRequest(http.MethodGet, "/query?log_level="+url.QueryEscape(valid), nil),
+		"/expired": httptest.NewRequest(http.MethodGet, "/expired", nil),
+		"/forged":  httptest.NewRequest(http.MethodGet, "/forged", nil),
+		"/none":    httptest.NewRequest(http.MethodGet, "/none", nil),
+	}
+	requests["/header"].Header.Set(LevelOverrideHeader, valid)
+	requests["/expired"].Header.Set(LevelOverrideHeader, expired)
+	requests["/forged"].Header.Set(LevelOverrideHeader, forged)
+
+	for _, r := range requests {
+		handler.ServeHTTP(httptest.NewRecorder(), r)
+	}
+
+	var texts []string
+	for _, message := range *messages {
+		texts = append(texts, message.Message)
+	}
+	assert.ElementsMatch(t, []string{"/header\n", "/query\n"}, texts)
+}
+
+func TestVerifyLevelOverride(t *testing.T) {
+	secret := []byte("secret")
+	now := time.Unix(1644965211, 0)
+
+	token := SignLevelOverride(secret, VERBOSE, now.Add(time.Minute))
+	level, ok := verifyLevelOverride(secret, token, now)
+	assert.True(t, ok)
+	assert.Equal(t, VERBOSE, level)
+
+	_, ok = verifyLevelOverride(secret, token, now.Add(2*time.Minute))
+	assert.False(t, ok)
+
+	_, ok = verifyLevelOverride(secret, "VERBOSE.1644965271.deadbeef", now)
+	assert.False(t, ok)
+
+	_, ok = verifyLevelOverride(secret, "", now)
+	assert.False(t, ok)
+
+	_, ok = verifyLevelOverride(nil, SignLevelOverride(nil, VERBOSE, now.Add(time.Minute)), now)
+	assert.False(t, ok)
+}
+
+func TestLevelOverrideRequiresSecret(t *testing.T) {
+	assert.Panics(t, func() { LevelOverride(nil) })
+	assert.Panics(t, func() { LevelOverride([]byte{}) })
+}
+
+func TestAccessLog(t *testing.T) {
+	messages := captureMessages(t)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/users", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("hello"))
+	})
+	mux.HandleFunc("/missing", http.NotFound)
+	mux.HandleFunc("/fail", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {})
+
+	handler := AccessLog(AccessLogConfig{SkipPaths: []string{"/healthz"}})(mux)
+
+	for _, path := range []string{"/users", "/missing", "/fail", "/healthz"} {
+		r := httptest.NewRequest(http.MethodGet, path, nil)
+		r.Header.Set("User-Agent", "curl/7.79.1")
+		r.Header.Set("X-Request-ID", "req-1")
+		handler.ServeHTTP(httptest.NewRecorder(), r)
+	}
+
+	assert.Len(t, *messages, 3)
+
+	users := (*messages)[0]
+	assert.Equal(t, INFO, users.Level)
+	assert.Equal(t, "GET /users\n", users.Message)
+	for key, expected := range map[string]interface{}{
+		"method":     "GET",
+		"path":       "/users",
+		"status":     200,
+		"bytes":      int64(5),
+		"remote":     "192.0.2.1:1234",
+		"user_agent": "curl/7.79.1",
+		"request_id": "req-1",
+	} {
+		value, _ := users.Field(key)
+		assert.Equal(t, expected, value, key)
+	}
+	_, ok := users.Field("duration")
+	assert.True(t, ok)
+
+	assert.Equal(t, WARN, (*messages)[1].Level)
+	assert.Equal(t, ERROR, (*messages)[2].Level)
+}
+
+func TestAccessLogCombined(t *testing.T) {
+	messages := captureMessages(t)
+
+	handler := AccessLog(AccessLogConfig{Format: AccessLogCombined})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("created"))
+	}))
+
+	r := httptest.NewRequest(http.MethodPost, "/users?active=true", nil)
+	r.SetBasicAuth("frank", "secret")
+	r.Header.Set("Referer", "http://example.com/")
+	r.Header.Set("User-Agent", "Mozilla/5.0")
+	handler.ServeHTTP(httptest.NewRecorder(), r)
+
+	assert.Len(t, *messages, 1)
+	assert.Regexp(t, `^192\.0\.2\.1 - frank \[\d{2}/\w{3}/\d{4}:\d{2}:\d{2}:\d{2} [+-]\d{4}\] "POST /users\?active=true HTTP/1\.1" 200 7 "http://example\.com/" "Mozilla/5\.0"\n$`, (*messages)[0].Message)
+}
+
+func TestAccessLogHidesLevelOverride(t *testing.T) {
+	messages := captureMessages(t)
+	SetLogLevel(INFO)
+
+	secret := []byte("secret")
+	token := SignLevelOverride(secret, DEBUG, time.Now().Add(time.Hour))
+
+	accessLog := AccessLog(AccessLogConfig{Format: AccessLogCombined})
+	handler := accessLog(LevelOverride(secret)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		WithContext(r.Context()).Println(DEBUG, "debug")
+		w.WriteHeader(http.StatusInternalServerError)
+	})))
+
+	r := httptest.NewRequest(http.MethodGet, "/users?active=true&log_level="+url.QueryEscape(token)+"&page=2", nil)
+	r.Header.Set("Referer", "http://example.com/?log_level="+url.QueryEscape(token))
+	handler.ServeHTTP(httptest.NewRecorder(), r)
+
+	assert.Len(t, *messages, 2)
+	assert.Equal(t, "debug\n", (*messages)[0].Message)
+
+	access := (*messages)[1]
+	assert.Contains(t, access.Message, `"GET /users?active=true&page=2 HTTP/1.1"`)
+	assert.Contains(t, access.Message, `"http://example.com/"`)
+	assert.NotContains(t, access.Message, token)
+	assert.NotContains(t, access.Message, url.QueryEscape(token))
+
+	// the caller would always be the access log middleware
+	assert.Equal(t, Caller{}, access.Caller)
+	assert.NotContains(t, stringify(access), "logAccess")
+}
+
+func TestWithoutLevelOverride(t *testing.T) {
+	tests := map[string]string{
+		"/users":                          "/users",
+		"/users?page=2":                   "/users?page=2",
+		"/users?log_level=x":              "/users",
+		"/users?log_level=x&page=2#top":   "/users?page=2#top",
+		"/users?a=1&log%5Flevel=x&b=2":    "/users?a=1&b=2",
+		"/users?log_level_hint=x&page=2":  "/users?log_level_hint=x&page=2",
+		"http://example.com/?log_level=x": "http://example.com/",
+	}
+	for uri, expected := range tests {
+		assert.Equal(t, expected, withoutLevelOverride(uri), uri)
+	}
+}
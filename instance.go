@@ -0,0 +1,145 @@
+package log
+
+// New returns a new Logger using the global configuration
+func New() *Logger {
+	return &Logger{}
+}
+
+// WithCallerSkip returns a Logger which skips n additional frames when resolving the caller.
+// This is useful for wrapper functions around the log functions.
+func WithCallerSkip(n int) *Logger {
+	return &Logger{callerSkip: n}
+}
+
+// WithCallerSkip returns a copy of the Logger which skips n additional frames when resolving the caller
+func (l *Logger) WithCallerSkip(n int) *Logger {
+	clone := *l
+	clone.callerSkip += n
+	return &clone
+}
+
+// With returns a Logger which adds the given fields to every message
+func With(fields ...Field) *Logger {
+	return New().With(fields...)
+}
+
+// With returns a copy of the Logger which adds the given fields to every message
+func (l *Logger) With(fields ...Field) *Logger {
+	clone := *l
+	clone.fields = append(l.fields[:len(l.fields):len(l.fields)], fields...)
+	return &clone
+}
+
+// Log logs the message with the given fields at the given LogLevel, a newline is appended.
+// For disabled levels Log returns without allocating.
+func (l *Logger) Log(level LogLevel, msg string, fields ...Field) {
+	if l.skip(level, callLog, msg, nil, fields) {
+		return
+	}
+
+	clone := *l
+	clone.fields = append(l.fields[:len(l.fields):len(l.fields)], fields...)
+	clone.println(level, msg)
+}
+
+// Enabled returns if a message of the given LogLevel would be passed to at least one handler
+// or captured by the debug buffer of the Logger.
+// Middleware is not evaluated, so if any middleware is set, all levels shown by the LogLevel are enabled.
+// The flight recorder does not enable a level, it records disabled log calls without building the message.
+func (l *Logger) Enabled(level LogLevel) bool {
+	if !l.shows(level) {
+		return l.captures(level)
+	}
+	return len(l.levelConfig(level).Handlers) > 0 || len(middleware) > 0
+}
+
+// skip returns if a log call of the given LogLevel is not passed to any handler and records it in the
+// flight recorder instead. Every entry point calls skip before the parameters are evaluated.
+func (l *Logger) skip(level LogLevel, kind callKind, text string, params []interface{}, fields []Field) bool {
+	if l.Enabled(level) {
+		return false
+	}
+	if r := flightRecorder; r != nil && r.disabledLevels {
+		r.recordCall(level, l.name, kind, text, params, l.fields, fields)
+	}
+	return true
+}
+
+// captures returns if a message of the given LogLevel is captured by the debug buffer of the Logger
+// even if the level is not shown
+func (l *Logger) captures(level LogLevel) bool {
+	return level != NONE && l.buffer != nil
+}
+
+// Println logs a message at the defined LogLevel a newline is appended
+func (l *Logger) Println(params ...interface{}) {
+	level, _, params := getLogLevel(false, params...)
+	l.println(level, params...)
+}
+
+// Print logs a message at the defined LogLevel
+func (l *Logger) Print(params ...interface{}) {
+	level, _, params := getLogLevel(false, params...)
+	l.print(level, params...)
+}
+
+// Printf logs a message at the defined LogLevel and formats the message according to a format specifier
+func (l *Logger) Printf(paramsOriginal ...interface{}) {
+	level, format, params := getLogLevel(true, paramsOriginal...)
+	if l.skip(level, callPrintf, format, params, nil) {
+		return
+	}
+	l.print(level, newFormatted(format, params))
+}
+
+// PrettyPrint logs a message at the defined LogLevel formatted as JSON, see PrettyPrint
+func (l *Logger) PrettyPrint(params ...interface{}) {
+	level, _, params := getLogLevel(false, params...)
+	if l.skip(level, callPrintln, "", params, nil) {
+		return
+	}
+	params = evaluateLazy(params)
+	b, err := marshalPretty(params, "  ")
+	if err != nil {
+		l.println(level, sdump(dumpConfig, params...))
+		return
+	}
+
+	l.println(level, prettyText(b))
+}
+
+// Sprintln returns the log message of Println as string
+func (l *Logger) Sprintln(params ...interface{}) string {
+	level, _, params := getLogLevel(false, params...)
+	return l.sprintln(level, params...)
+}
+
+// Sprint returns the log message of Print as string
+func (l *Logger) Sprint(params ...interface{}) string {
+	level, _, params := getLogLevel(false, params...)
+	return l.sprint(level, params...)
+}
+
+// Sprintf returns the log message of Printf as string
+func (l *Logger) Sprintf(paramsOriginal ...interface{}) string {
+	level, format, params := getLogLevel(true, paramsOriginal...)
+	if !l.shows(level) {
+		return ""
+	}
+	return l.sprint(level, newFormatted(format, params))
+}
+
+// SprettyPrint returns the log message of PrettyPrint as string
+func (l *Logger) SprettyPrint(params ...interface{}) string {
+	level, _, params := getLogLevel(false, params...)
+	if !l.shows(level) {
+		return ""
+	}
+	params = evaluateLazy(params)
+	b, err := marshalPretty(params, "  ")
+	if err != nil {
+		return l.sprintln(level, sdump(dumpConfig, params...))
+	}
+
+	return l.sprintln(level, prettyText(b))
+}
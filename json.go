@@ -0,0 +1,91 @@
+package log
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+)
+
+// JSONHandler returns a Handler which writes every message as a single line JSON object to w
+func JSONHandler(w io.Writer) Handler {
+	mu := sync.Mutex{}
+	return func(message Message) {
+		b := FormatJSON(message)
+
+		mu.Lock()
+		defer mu.Unlock()
+		_, _ = w.Write(append(b, '\n'))
+	}
+}
+
+// FormatJSON returns the message as JSON object.
+// Field values are rendered honoring the log struct tags.
+func FormatJSON(message Message) []byte {
+	object := prettyObject{
+		{Key: "time", Value: message.Time.Format(time.RFC3339Nano)},
+		{Key: "level", Value: message.Level.String()},
+	}
+
+	if message.Name != "" {
+		object = append(object, prettyEntry{Key: "logger", Value: message.Name})
+	}
+
+	if message.Caller != (Caller{}) {
+		object = append(object, prettyEntry{Key: "caller", Value: prettyObject{
+			{Key: "path", Value: message.Caller.Path},
+			{Key: "function", Value: message.Caller.FunctionName},
+			{Key: "line", Value: int64(message.Caller.LineNumber)},
+		}})
+	}
+
+	object = append(object, prettyEntry{Key: "message", Value: strings.TrimSuffix(message.Message, "\n")})
+
+	if len(message.Fields) > 0 {
+		fields := make(prettyObject, 0, len(message.Fields))
+		for _, f := range message.Fields {
+			fields = append(fields, prettyEntry{Key: f.Key, Value: f.encodeJSON()})
+		}
+		object = append(object, prettyEntry{Key: "fields", Value: fields})
+	}
+
+	if len(message.Errors) > 0 {
+		object = append(object, prettyEntry{Key: "errors", Value: errorsToPretty(message.Errors)})
+	}
+
+	if len(message.Stack) > 0 {
+		object = append(object, prettyEntry{Key: "stack", Value: framesToPretty(message.Stack)})
+	}
+
+	var sb strings.Builder
+	_ = writePrettyJSON(&sb, object, "", 0)
+	return []byte(sb.String())
+}
+
+// framesToPretty converts the frames of a stack trace to a pretty tree
+func framesToPretty(stack []Frame) []interface{} {
+	frames := make([]interface{}, len(stack))
+	for i, frame := range stack {
+		frames[i] = prettyObject{
+			{Key: "function", Value: frame.Function},
+			{Key: "file", Value: frame.File},
+			{Key: "line", Value: int64(frame.Line)},
+		}
+	}
+	return frames
+}
+
+// jsonValue converts a field value to a pretty tree.
+// Errors are rendered with their message, values which can not be rendered are formatted with fmt.
+func jsonValue(value interface{}) interface{} {
+	if err, ok := value.(error); ok {
+		return errorMessage(err)
+	}
+
+	tree, err := toPretty(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	return tree
+}
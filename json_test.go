@@ -0,0 +1,41 @@
+package log
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFormatJSON(t *testing.T) {
+	msg := Message{
+		Time:    time.Date(2022, 2, 15, 22, 32, 38, 0, time.UTC),
+		Level:   ERROR,
+		Caller:  Caller{Path: "main.go", FunctionName: "main", LineNumber: 12},
+		Message: "failed\n",
+		Stack:   []Frame{{Function: "main.main", File: "/app/main.go", Line: 12}},
+	}
+	msg.SetField("user", credentials{User: "bob", Password: "hunter2"})
+	msg.SetField("err", errors.New("boom"))
+
+	expected := `{"time":"2022-02-15T22:32:38Z","level":"ERROR",` +
+		`"caller":{"path":"main.go","function":"main","line":12},"message":"failed",` +
+		`"fields":{"user":{"user":"bob","Password":"[REDACTED]","Internal":"","permission":""},"err":"boom"},` +
+		`"stack":[{"function":"main.main","file":"/app/main.go","line":12}]}`
+
+	assert.Equal(t, expected, string(FormatJSON(msg)))
+}
+
+func TestJSONHandler(t *testing.T) {
+	buf := &bytes.Buffer{}
+	handler := JSONHandler(buf)
+
+	handler(Message{Level: INFO, Message: "one"})
+	handler(Message{Level: WARN, Message: "two"})
+
+	lines := bytes.Split(bytes.TrimSpace(buf.Bytes()), []byte("\n"))
+	assert.Len(t, lines, 2)
+	assert.Contains(t, string(lines[1]), `"level":"WARN","message":"two"`)
+}
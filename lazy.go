@@ -0,0 +1,58 @@
+package log
+
+// Lazy is a value which is only computed if the message is emitted.
+// Lazy values and functions of type func() interface{} can be passed as parameter to all log functions.
+type Lazy func() interface{}
+
+// evaluateLazy returns params with all lazy values replaced by their results.
+// params is returned unchanged if it does not contain lazy values.
+func evaluateLazy(params []interface{}) []interface{} {
+	found := false
+	for _, param := range params {
+		if isLazy(param) {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return params
+	}
+
+	evaluated := make([]interface{}, len(params))
+	for i, param := range params {
+		if f, ok := param.(formatted); ok {
+			args := make([]interface{}, len(f.args))
+			for j, arg := range f.args {
+				args[j] = evaluate(arg)
+			}
+			param = formatted{format: f.format, args: args}
+		}
+		evaluated[i] = evaluate(param)
+	}
+	return evaluated
+}
+
+// evaluate returns the result of a lazy value or the value itself
+func evaluate(param interface{}) interface{} {
+	switch p := param.(type) {
+	case Lazy:
+		return p()
+	case func() interface{}:
+		return p()
+	}
+	return param
+}
+
+func isLazy(param interface{}) bool {
+	switch p := param.(type) {
+	case Lazy, func() interface{}:
+		return true
+	case formatted:
+		for _, arg := range p.args {
+			if isLazy(arg) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,52 @@
+package log
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEnabled(t *testing.T) {
+	captureMessages(t)
+	SetLogLevel(INFO)
+
+	assert.True(t, Enabled(INFO))
+	assert.True(t, New().Enabled(ERROR))
+	assert.False(t, Enabled(DEBUG))
+	assert.False(t, Enabled(NONE))
+
+	config.Warn.SetHandlers(nil)
+	assert.False(t, Enabled(WARN))
+
+	AddMiddleware(Tee(func(message Message) {}))
+	assert.True(t, Enabled(WARN))
+}
+
+func TestLazyEvaluation(t *testing.T) {
+	messages := captureMessages(t)
+	SetLogLevel(INFO)
+	config.Warn.SetHandlers(nil)
+
+	calls := 0
+	expensive := Lazy(func() interface{} {
+		calls++
+		return map[string]int{"items": 3}
+	})
+
+	Println(DEBUG, "state", expensive)
+	PrettyPrint(DEBUG, expensive)
+	Dump(DEBUG, expensive)
+	Println(WARN, "no handler", expensive)
+	assert.Equal(t, 0, calls)
+	assert.Empty(t, *messages)
+
+	Println(INFO, "state", expensive)
+	Printf(INFO, "state %v", func() interface{} { calls++; return "ok" })
+	PrettyPrint(INFO, expensive)
+
+	assert.Equal(t, 3, calls)
+	assert.Len(t, *messages, 3)
+	assert.Equal(t, "statemap[items:3]\n", (*messages)[0].Message)
+	assert.Equal(t, "state ok", (*messages)[1].Message)
+	assert.Contains(t, (*messages)[2].Message, `"items": 3`)
+}
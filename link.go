@@ -0,0 +1,91 @@
+package log
+
+import (
+	"net/url"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// LinkMode defines the target of the OSC 8 hyperlink of the caller section
+type LinkMode uint
+
+const (
+	// LinkNone disables hyperlinks
+	LinkNone LinkMode = iota
+	// LinkFile links to the source file with a file:// URL
+	LinkFile
+	// LinkEditor links to the source file with the URL template set by SetEditorURL
+	LinkEditor
+	// LinkVCS links to the source file at the commit the binary was built from with the URL template set by SetVCSURL.
+	// If the revision is not known, the link falls back to LinkFile.
+	LinkVCS
+)
+
+// DefaultEditorURL opens the file in Visual Studio Code
+const DefaultEditorURL = "vscode://file/{path}:{line}"
+
+var callerLinkMode = LinkNone
+var editorURL = DefaultEditorURL
+var vcsURL = ""
+
+var vcsRevision string
+var vcsRevisionOnce sync.Once
+
+// SetCallerLinks defines if the caller section is rendered as OSC 8 hyperlink.
+// Like colors, links are only emitted if the output is a terminal or colors in logs are enabled.
+//
+// Default is LinkNone
+func SetCallerLinks(mode LinkMode) {
+	callerLinkMode = mode
+}
+
+// SetEditorURL sets the URL template used by LinkEditor.
+// The placeholders {path} and {line} are replaced by the absolute file path and the line number.
+//
+// Default is DefaultEditorURL
+func SetEditorURL(template string) {
+	editorURL = template
+}
+
+// SetVCSURL sets the URL template used by LinkVCS, e.g. https://github.com/org/app/blob/{revision}/{path}#L{line}.
+// The placeholders {revision}, {path} and {line} are replaced by the VCS revision of the build,
+// the file path relative to the module root and the line number.
+func SetVCSURL(template string) {
+	vcsURL = template
+}
+
+// callerURL returns the link target of the caller or an empty string if no link should be rendered
+func callerURL(caller Caller) string {
+	if caller.File == "" {
+		return ""
+	}
+
+	line := strconv.Itoa(caller.LineNumber)
+
+	switch callerLinkMode {
+	case LinkEditor:
+		return strings.NewReplacer("{path}", filepath.ToSlash(caller.File), "{line}", line).Replace(editorURL)
+	case LinkVCS:
+		if revision := getVCSRevision(); revision != "" && vcsURL != "" {
+			return strings.NewReplacer(
+				"{revision}", revision,
+				"{path}", modulePath(caller.Function, caller.File),
+				"{line}", line,
+			).Replace(vcsURL)
+		}
+		fallthrough
+	case LinkFile:
+		return (&url.URL{Scheme: "file", Path: filepath.ToSlash(caller.File)}).String()
+	}
+	return ""
+}
+
+// getVCSRevision returns the VCS revision the binary was built from
+func getVCSRevision() string {
+	vcsRevisionOnce.Do(func() {
+		vcsRevision = readVCSRevision()
+	})
+	return vcsRevision
+}
@@ -0,0 +1,63 @@
+package log
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCallerURL(t *testing.T) {
+	defer SetCallerLinks(LinkNone)
+	defer SetEditorURL(DefaultEditorURL)
+	defer SetVCSURL("")
+
+	caller := Caller{
+		Path:       "db/pool.go",
+		LineNumber: 42,
+		Function:   "github.com/chris-dot-exe/AwesomeLog/db.(*Pool).Get",
+		File:       "/src/my app/db/pool.go",
+	}
+
+	assert.Equal(t, "", callerURL(caller))
+
+	SetCallerLinks(LinkFile)
+	assert.Equal(t, "file:///src/my%20app/db/pool.go", callerURL(caller))
+
+	SetCallerLinks(LinkEditor)
+	assert.Equal(t, "vscode://file//src/my app/db/pool.go:42", callerURL(caller))
+	SetEditorURL("idea://open?file={path}&line={line}")
+	assert.Equal(t, "idea://open?file=/src/my app/db/pool.go&line=42", callerURL(caller))
+
+	vcsRevisionOnce.Do(func() {})
+	defer func(revision string) { vcsRevision = revision }(vcsRevision)
+	mainModuleOnce.Do(func() {})
+	defer func(module string) { mainModule = module }(mainModule)
+	vcsRevision = "abc123"
+	mainModule = "github.com/chris-dot-exe/AwesomeLog"
+
+	SetCallerLinks(LinkVCS)
+	assert.Equal(t, "file:///src/my%20app/db/pool.go", callerURL(caller))
+	SetVCSURL("https://github.com/chris-dot-exe/AwesomeLog/blob/{revision}/{path}#L{line}")
+	assert.Equal(t, "https://github.com/chris-dot-exe/AwesomeLog/blob/abc123/db/pool.go#L42", callerURL(caller))
+
+	defer func(pkg string) { mainPackage = pkg }(mainPackage)
+	mainPackage = "github.com/chris-dot-exe/AwesomeLog/cmd/probe"
+	caller = Caller{LineNumber: 8, Function: "main.main", File: "/src/my app/cmd/probe/main.go"}
+	assert.Equal(t, "https://github.com/chris-dot-exe/AwesomeLog/blob/abc123/cmd/probe/main.go#L8", callerURL(caller))
+}
+
+func TestCallerLinkInOutput(t *testing.T) {
+	defer SetCallerLinks(LinkNone)
+	defer ShowColorsInLogs(false)
+	defer ShowTimestamp(true)
+	ShowColors(true)
+	ShowColorsInLogs(true)
+	ShowTimestamp(false)
+	SetCallerLinks(LinkFile)
+
+	message := Message{Level: ERROR, Message: "msg", Caller: Caller{Path: "main.go", FunctionName: "main", LineNumber: 1, File: "/src/main.go"}}
+	assert.Contains(t, stringify(message), "\x1b]8;;file:///src/main.go\x1b\\[main.go:main:1]\x1b]8;;\x1b\\ msg")
+
+	message.Caller.File = ""
+	assert.Contains(t, stringify(message), ANSI_RESET+"[main.go:main:1] msg")
+}
@@ -1,34 +1,35 @@
 package log
 
 import (
-	"encoding/json"
-	"errors"
+	"bytes"
 	"fmt"
 	log2 "log"
 	"os"
-	"path"
-	"path/filepath"
 	"reflect"
-	"runtime"
+	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
-	"golang.org/x/text/language"
-
-	"github.com/fatih/structs"
 	"golang.org/x/term"
-	"golang.org/x/text/cases"
 )
 
 func init() {
 	config = DefaultLevelConfig()
 }
 
+// Enabled returns if a message of the given LogLevel would be passed to at least one handler.
+// Use Enabled to skip expensive computations of log messages which would be dropped.
+func Enabled(level LogLevel) bool {
+	return std.Enabled(level)
+}
+
 // SetLogLevel defines to which LogLevel log messages should be shown.
 //
 // Default is VERBOSE
 func SetLogLevel(lvl LogLevel) {
-	logLevel = lvl
+	atomic.StoreUint32(&logLevel, uint32(lvl))
 }
 
 // ShowCaller defines if the caller (function name, line number, file path) should be shown on a global level.
@@ -37,16 +38,13 @@ func ShowCaller(show bool) {
 		config = DefaultLevelConfig()
 	}
 
-	s := structs.New(config)
-
-	for _, value := range s.Fields() {
-
-		sfn := value.Field("ShowFunctionName")
-		sfp := value.Field("ShowFilePath")
-		sln := value.Field("ShowLineNumber")
-		sfn.Set(show)
-		sfp.Set(show)
-		sln.Set(show)
+	for _, cfg := range config.levels() {
+		if cfg == nil {
+			continue
+		}
+		cfg.ShowFunctionName = show
+		cfg.ShowFilePath = show
+		cfg.ShowLineNumber = show
 	}
 }
 
@@ -64,7 +62,7 @@ func SetLogLevelByString(lvlStr string) {
 		log2.Fatalf("LogLevel '%s' is not supported!\n", lvlStr)
 		return
 	}
-	logLevel = val
+	SetLogLevel(val)
 }
 
 // SetDefaultLevel defines which LogLevel should be used if no LogLevel is provided.
@@ -89,6 +87,7 @@ func ShowTimestamp(show bool) {
 // SetCallerMaxDepth set the max depth of the callers file path
 func SetCallerMaxDepth(depth int) {
 	maxDepthOfCallerPath = depth
+	resetCallerCache()
 }
 
 // DefaultLevelConfig return the default level config for AwesomeLog
@@ -144,59 +143,72 @@ func SetTimeFormat(format string) {
 	timeFormat = format
 }
 
+// Log logs the message with the given fields at the given LogLevel, a newline is appended.
+// For disabled levels Log returns without allocating.
+func Log(level LogLevel, msg string, fields ...Field) {
+	std.Log(level, msg, fields...)
+}
+
 // Println logs a message at the defined LogLevel a newline is appended
 func Println(params ...interface{}) {
-	level, _, params := getLogLevel(false, params...)
-	println(level, params...)
+	std.Println(params...)
 }
 
 // Print logs a message at the defined LogLevel
 func Print(params ...interface{}) {
-	level, _, params := getLogLevel(false, params...)
-	print(level, params...)
+	std.Print(params...)
 }
 
 // Printf logs a message at the defined LogLevel and formats the message according to a format specifier
 func Printf(paramsOriginal ...interface{}) {
-	level, format, params := getLogLevel(true, paramsOriginal...)
-	print(level, fmt.Sprintf(format, params...))
+	std.Printf(paramsOriginal...)
 }
 
 // PrettyPrint logs a message at the defined LogLevel formatted as JSON
 // Works only with exported fields.
+//
+// The output can be controlled with the struct tag `log:"..."` independent of JSON tags:
+//   - `log:"-"` hides the field
+//   - `log:"redact"` masks the value of the field
+//   - `log:"omitempty"` hides the field if it is empty
+//   - `log:"name=..."` renames the field
+//
+// Values which can not be rendered as JSON, e.g. channels or cyclic pointers, are rendered like Dump.
 func PrettyPrint(params ...interface{}) {
-	level, _, params := getLogLevel(false, params...)
-	b, err := json.MarshalIndent(params, "", "  ")
-	if err != nil {
-		Fatal("unsupported input. error: ", err)
-	}
-
-	println(level, string(b))
+	std.PrettyPrint(params...)
 }
 
 func Sprintln(params ...interface{}) string {
-	level, _, params := getLogLevel(false, params...)
-	return sprintln(level, params...)
+	return std.Sprintln(params...)
 }
 
 func Sprint(params ...interface{}) string {
-	level, _, params := getLogLevel(false, params...)
-	return sprint(level, params)
+	return std.Sprint(params...)
 }
 
 func Sprintf(paramsOriginal ...interface{}) string {
-	level, format, params := getLogLevel(true, paramsOriginal...)
-	return sprint(level, fmt.Sprintf(format, params...))
+	return std.Sprintf(paramsOriginal...)
 }
 
 func SprettyPrint(params ...interface{}) string {
-	level, _, params := getLogLevel(false, params...)
-	b, err := json.MarshalIndent(params, "", "  ")
-	if err != nil {
-		Fatal("unsupported input. error: ", err)
-	}
+	return std.SprettyPrint(params...)
+}
+
+// formatted is a message which is formatted according to a format specifier.
+// The arguments are kept to detect errors.
+type formatted struct {
+	format string
+	args   []interface{}
+}
 
-	return sprintln(level, string(b))
+// newFormatted returns a formatted message with a copy of args,
+// so the arguments of disabled log calls do not escape to the heap
+func newFormatted(format string, args []interface{}) formatted {
+	return formatted{format: format, args: append([]interface{}(nil), args...)}
+}
+
+func (f formatted) String() string {
+	return fmt.Sprintf(f.format, f.args...)
 }
 
 // region fatal
@@ -243,172 +255,379 @@ func Panicln(params ...interface{}) {
 
 // endregion panic
 
+// bufferPool contains the buffers used to build log messages
+var bufferPool = sync.Pool{
+	New: func() interface{} {
+		return new(bytes.Buffer)
+	},
+}
+
 // stringify builds the log message string with colors and caller
 func stringify(message Message) string {
+	buf := bufferPool.Get().(*bytes.Buffer)
+	buf.Reset()
+	writeMessage(buf, message, useColors())
+	output := buf.String()
+	bufferPool.Put(buf)
 
-	if config == nil {
-		config = DefaultLevelConfig()
-	}
-
-	lvlName := strings.Title(strings.ToLower(message.Level.String()))
+	return output
+}
 
-	s := structs.New(config)
-	lvlField := s.Field(lvlName)
+// useColors returns if colors are enabled and the output is a terminal or colors in logs are enabled
+func useColors() bool {
+	return showColors && (colorsInLogs || isTerminal())
+}
 
-	cfg := lvlField.Value().(LevelConfig)
+// writeMessage writes the log message with caller to buf. If colored is set the level tag is colored.
+func writeMessage(buf *bytes.Buffer, message Message, colored bool) {
+	cfg := levelConfigFor(message.Name, message.Level)
 
-	prefix := ""
-	caller := ""
+	var scratch [64]byte
 
 	if showTimestamp {
-		prefix = fmt.Sprintf("%s ", message.Time.Format(timeFormat))
+		buf.Write(message.Time.AppendFormat(scratch[:0], timeFormat))
+		buf.WriteByte(' ')
 	}
 
-	if showColors && (colorsInLogs || isTerminal()) {
-		prefix += fmt.Sprintf(message.Level.Color()+"[%s]"+ANSI_RESET, message.Level.String())
+	text := message.Message
+
+	if colored {
+		buf.WriteString(message.Level.Color())
+		buf.WriteByte('[')
+		buf.WriteString(message.Level.String())
+		buf.WriteByte(']')
+		buf.WriteString(ANSI_RESET)
+		if highlightPretty && message.pretty {
+			text = highlightJSON(text, prettyTheme)
+		}
 	} else {
-		prefix += fmt.Sprintf("[%s]", message.Level.String())
+		buf.WriteByte('[')
+		buf.WriteString(message.Level.String())
+		buf.WriteByte(']')
 	}
 
-	if cfg.ShowFilePath || cfg.ShowFunctionName || cfg.ShowLineNumber {
-		caller += "["
-		if cfg.ShowFilePath {
+	if message.Name != "" {
+		buf.WriteByte('[')
+		buf.WriteString(message.Name)
+		buf.WriteByte(']')
+	}
+
+	if (cfg.ShowFilePath || cfg.ShowFunctionName || cfg.ShowLineNumber) && message.Caller != (Caller{}) {
+		link := ""
+		if colored {
+			link = callerURL(message.Caller)
+		}
+		if link != "" {
+			buf.WriteString("\x1b]8;;")
+			buf.WriteString(link)
+			buf.WriteString("\x1b\\")
+		}
 
-			caller += fmt.Sprintf("%s:", message.Caller.Path)
+		buf.WriteByte('[')
+		if cfg.ShowFilePath {
+			buf.WriteString(message.Caller.Path)
+			buf.WriteByte(':')
 		}
 		if cfg.ShowFunctionName {
-			caller += fmt.Sprintf("%s", message.Caller.FunctionName)
+			if showQualifiedFunctionName && message.Caller.Function != "" {
+				buf.WriteString(message.Caller.Function)
+			} else {
+				buf.WriteString(message.Caller.FunctionName)
+			}
 		}
 		if cfg.ShowLineNumber {
-			caller += fmt.Sprintf(":%d", message.Caller.LineNumber)
+			buf.WriteByte(':')
+			buf.Write(strconv.AppendInt(scratch[:0], int64(message.Caller.LineNumber), 10))
 		}
+		buf.WriteByte(']')
 
-		caller += "]"
+		if link != "" {
+			buf.WriteString("\x1b]8;;\x1b\\")
+		}
 	}
-	return fmt.Sprintf("%s%s %s", prefix, caller, message.Message)
+
+	buf.WriteByte(' ')
+	buf.WriteString(appendStack(appendErrors(appendFields(text, message.Fields), message.Errors), message.Stack))
 }
 
-// buildMessage builds the Message object used by all log handlers
-func buildMessage(level LogLevel, params ...interface{}) Message {
-	now := time.Now()
-	caller := Caller{}
+// appendFields appends the fields as key=value pairs to the message text.
+// A trailing newline of the message is preserved.
+func appendFields(text string, fields []Field) string {
+	if len(fields) == 0 {
+		return text
+	}
 
-	fpcs := make([]uintptr, 1)
-	n := runtime.Callers(5, fpcs)
-	relpath, name, row, err := getCaller(n, fpcs)
+	trimmed := strings.TrimSuffix(text, "\n")
+	newline := text[len(trimmed):]
 
-	if maxDepthOfCallerPath > 0 {
-		pathElements := strings.Split(relpath, string(os.PathSeparator))
-		length := len(pathElements)
+	var sb strings.Builder
+	sb.WriteString(trimmed)
+	for _, f := range fields {
+		sb.WriteString(" ")
+		sb.WriteString(f.Key)
+		sb.WriteString("=")
+		sb.WriteString(f.encodeText())
+	}
+	sb.WriteString(newline)
 
-		start := length - maxDepthOfCallerPath
-		if start < 0 {
-			start = 0
-		} else {
-			relpath = "..." + string(os.PathSeparator)
+	return sb.String()
+}
+
+// formatFieldValue formats a field value and quotes it if necessary.
+// Structs, maps and slices are rendered as JSON honoring the log struct tags.
+func formatFieldValue(value interface{}) string {
+	v := reflect.Indirect(reflect.ValueOf(value))
+	switch v.Kind() {
+	case reflect.Struct, reflect.Map, reflect.Slice, reflect.Array:
+		if _, ok := value.(fmt.Stringer); !ok {
+			if str, err := marshalPretty(value, ""); err == nil {
+				return str
+			}
 		}
-		relpath += path.Join(pathElements[start:length]...)
 	}
 
-	if err == nil {
-		caller.Path = relpath
-		caller.FunctionName = name
-		caller.LineNumber = row
+	return quoteFieldValue(fmt.Sprint(value))
+}
+
+// buildMessage builds the Message object used by all log handlers
+func (l *Logger) buildMessage(level LogLevel, params ...interface{}) Message {
+	now := time.Now()
+
+	// skip runtime.Callers, buildMessage, logHandler or format, the internal print function
+	// and the public function called by the user
+	var caller Caller
+	if !l.hideCaller {
+		caller, _ = resolveCaller(5 + l.callerSkip)
 	}
 
+	params = evaluateLazy(params)
+	fields, params := splitFields(l.fields, params)
+
 	msg := Message{
 		Time:    now,
 		Level:   level,
+		Name:    l.name,
 		Caller:  caller,
 		Message: fmt.Sprint(params...),
+		Fields:  fields,
+		Errors:  collectErrors(params),
+	}
+
+	if l.levelConfig(level).CaptureStack {
+		msg.Stack = captureStack(6 + l.callerSkip)
+	}
+
+	for _, param := range params {
+		if _, ok := param.(prettyText); ok {
+			msg.pretty = true
+		}
+	}
+
+	if redactor != nil {
+		msg = redactor.redactMessage(msg)
 	}
 
 	return msg
 }
 
+// splitFields separates the Field parameters from the message parameters and appends them to fields
+func splitFields(fields []Field, params []interface{}) ([]Field, []interface{}) {
+	found := false
+	for _, param := range params {
+		if _, ok := param.(Field); ok {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return fields, params
+	}
+
+	fields = append(fields[:len(fields):len(fields)], make([]Field, 0, len(params))...)
+	rest := make([]interface{}, 0, len(params))
+	for _, param := range params {
+		if f, ok := param.(Field); ok {
+			fields = append(fields, f)
+		} else {
+			rest = append(rest, param)
+		}
+	}
+	return fields, rest
+}
+
 // logHandler calls all defined handlers with the built Message object
-func logHandler(level LogLevel, params ...interface{}) {
-	if !showMe(level) {
+func (l *Logger) logHandler(level LogLevel, params ...interface{}) {
+	if !l.shows(level) {
+		if l.captures(level) {
+			message := l.buildMessage(level, params...)
+			l.buffer.add(message)
+			flightRecorder.record(message)
+			return
+		}
+		flightRecorder.recordCall(level, l.name, callPrint, "", params, l.fields, nil)
 		return
 	}
 
-	if config == nil {
-		config = DefaultLevelConfig()
+	cfg := l.levelConfig(level)
+	if len(cfg.Handlers) == 0 && len(middleware) == 0 {
+		flightRecorder.recordCall(level, l.name, callPrint, "", params, l.fields, nil)
+		return
 	}
 
-	caser := cases.Title(language.AmericanEnglish)
-	caser.String(strings.ToLower(level.String()))
+	// skip logHandler, the internal print function and the public function called by the user
+	if l.gate != nil && !l.gate.pass(callerPC(3+l.callerSkip)) {
+		return
+	}
 
-	lvlName := caser.String(strings.ToLower(level.String()))
-	s := structs.New(config)
-	lvlField := s.Field(lvlName)
-	cfg := lvlField.Value().(LevelConfig)
+	// skip sample, logHandler, the internal print function and the public function called by the user
+	if !sample(cfg, level, l.name, 3+l.callerSkip, params) {
+		return
+	}
+	if cfg.Sampler != nil || cfg.RateLimiter != nil {
+		for _, summary := range suppressedSummaries(cfg) {
+			dispatch(cfg.Handlers, l.buildMessage(level, summary...))
+		}
+	}
 
-	message := buildMessage(level, params...)
+	message := l.buildMessage(level, params...)
+	flightRecorder.record(message)
 
-	for _, handler := range cfg.Handlers {
-		handler(message)
+	if l.buffer != nil && level <= l.buffer.trigger {
+		l.buffer.flush()
 	}
 
+	dispatch(cfg.Handlers, message)
 }
 
-// log is the internal log handler
-func log(message Message) {
+// format returns the built Message object as string
+func (l *Logger) format(level LogLevel, params ...interface{}) string {
+	if !l.shows(level) {
+		return ""
+	}
+
+	// skip format, the internal print function and the public function called by the user
+	if l.gate != nil && !l.gate.pass(callerPC(3+l.callerSkip)) {
+		return ""
+	}
 
-	logMessage := stringify(message)
+	message := l.buildMessage(level, params...)
+	message.formatOnly = true
+
+	if middlewareChain == nil {
+		return stringify(message)
+	}
+
+	// the chain is built for every call, so messages created by middleware can not reach the handlers
+	output := ""
+	Chain(func(message Message) {
+		if redactor != nil {
+			message = redactor.redactMessage(message)
+		}
+		output = stringify(message)
+	}, middleware...)(message)
 
-	fmt.Print(logMessage)
+	return output
 }
 
-func println(level LogLevel, params ...interface{}) {
-	params = append(params, "\n")
-	logHandler(level, params...)
+// levelSlots maps every LogLevel to its index in Config.levels, unknown levels are mapped to 0
+var levelSlots = [VERBOSE + 1]uint8{
+	CRITICAL: 1,
+	ERROR:    2,
+	WARN:     3,
+	INFO:     4,
+	DEBUG:    5,
+	VERBOSE:  6,
 }
 
-func print(level LogLevel, params ...interface{}) {
-	logHandler(level, params...)
+// levels returns the LevelConfigs indexed by levelSlots
+func (c *Config) levels() [7]*LevelConfig {
+	return [7]*LevelConfig{nil, &c.Critical, &c.Error, &c.Warn, &c.Info, &c.Debug, &c.Verbose}
 }
 
-func sprint(level LogLevel, params ...interface{}) string {
-	if !showMe(level) {
-		return ""
+// getLevelConfig returns the LevelConfig of the given LogLevel.
+// An empty LevelConfig is returned for unknown levels.
+func getLevelConfig(level LogLevel) LevelConfig {
+	cfg := config
+	if cfg == nil {
+		cfg = DefaultLevelConfig()
+		config = cfg
+	}
+
+	return cfg.levelConfig(level)
+}
+
+// levelConfig returns the LevelConfig of the given LogLevel, an empty LevelConfig is returned for unknown levels
+func (c *Config) levelConfig(level LogLevel) LevelConfig {
+	if level > VERBOSE {
+		return LevelConfig{}
+	}
+	if lvl := c.levels()[levelSlots[level]]; lvl != nil {
+		return *lvl
 	}
-	message := buildMessage(level, params...)
-	return stringify(message)
+	return LevelConfig{}
 }
 
-func sprintln(level LogLevel, params ...interface{}) string {
+// log is the internal log handler
+func log(message Message) {
+	buf := bufferPool.Get().(*bytes.Buffer)
+	buf.Reset()
+	writeMessage(buf, message, useColors())
+	_, _ = os.Stdout.Write(buf.Bytes())
+	bufferPool.Put(buf)
+}
+
+func (l *Logger) println(level LogLevel, params ...interface{}) {
+	if l.skip(level, callPrintln, "", params, nil) {
+		return
+	}
+	params = append(params, "\n")
+	l.logHandler(level, params...)
+}
+
+func (l *Logger) print(level LogLevel, params ...interface{}) {
+	l.logHandler(level, params...)
+}
+
+func (l *Logger) sprint(level LogLevel, params ...interface{}) string {
+	return l.format(level, params...)
+}
+
+func (l *Logger) sprintln(level LogLevel, params ...interface{}) string {
+	if !l.shows(level) {
+		return ""
+	}
 	params = append(params, "\n")
-	return sprint(level, params...)
+	return l.format(level, params...)
 }
 
+// showMe returns if messages of the given LogLevel are shown, the check costs a single atomic load
 func showMe(level LogLevel) bool {
-	if logLevel == NONE || level == NONE {
+	current := LogLevel(atomic.LoadUint32(&logLevel))
+	if current == NONE || level == NONE {
 		return false
 	}
 
-	return logLevel >= level
+	return current >= level
 }
 
 func getLogLevel(withFormat bool, values ...interface{}) (logLevel LogLevel, format string, newValues []interface{}) {
-
-	comp := LogLevel(0)
-	try := values[0]
 	level := defaultLevel
 
-	if reflect.TypeOf(try) == reflect.TypeOf(comp) {
-		val := reflect.ValueOf(try)
-		level = LogLevel(val.Uint())
-		values = values[1:]
+	if len(values) > 0 {
+		if lvl, ok := values[0].(LogLevel); ok {
+			level = lvl
+			values = values[1:]
+		}
 	}
 
 	format = ""
 	if withFormat {
-		try := values[0]
+		if len(values) == 0 {
+			log2.Panicln("please specify a format")
+		}
 
-		if reflect.TypeOf(try) == reflect.TypeOf(format) {
-			val := reflect.ValueOf(try)
-			format = val.String()
+		if str, ok := values[0].(string); ok {
+			format = str
 			values = values[1:]
 		} else {
 			log2.Panicln("please specify a format")
@@ -418,36 +637,6 @@ func getLogLevel(withFormat bool, values ...interface{}) (logLevel LogLevel, for
 	return level, format, values
 }
 
-func getCaller(n int, fpcs []uintptr) (relpath string, name string, row int, err error) {
-	err = nil
-
-	if n == 0 {
-		return "", "", -1, errors.New("MSG CALLER WAS NIL")
-	}
-
-	caller := runtime.FuncForPC(fpcs[0] - 1)
-	if caller == nil {
-		return "", "", -1, errors.New("MSG CALLER WAS NIL")
-	}
-
-	// Get Path
-	absPath, row := caller.FileLine(fpcs[0] - 1)
-	dir, err := os.Getwd()
-	if err != nil {
-		log2.Fatal(err)
-	}
-	relpath, err = filepath.Rel(dir, absPath)
-	if err != nil {
-		return "", "", -1, err
-	}
-
-	// Get Name of the caller function
-	na := strings.Split(caller.Name(), ".")
-	name = na[len(na)-1]
-
-	return
-}
-
 func isTerminal() bool {
 	return term.IsTerminal(int(os.Stdout.Fd()))
 }
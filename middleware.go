@@ -0,0 +1,135 @@
+package log
+
+import "sort"
+
+// AddMiddleware adds global middleware which runs before the handlers of every LogLevel.
+// Middleware is executed in the order it was added.
+func AddMiddleware(mw ...Middleware) {
+	SetMiddleware(append(middleware, mw...))
+}
+
+// SetMiddleware sets the global middleware.
+// SetMiddleware overrides the existing middleware
+func SetMiddleware(mw []Middleware) {
+	middleware = mw
+	middlewareChain = nil
+	if len(mw) > 0 {
+		middlewareChain = Chain(deliver, mw...)
+	}
+}
+
+// Chain wraps the handler with the given middleware.
+// The first middleware is the first to receive a message.
+func Chain(handler Handler, mw ...Middleware) Handler {
+	for i := len(mw) - 1; i >= 0; i-- {
+		handler = mw[i](handler)
+	}
+	return handler
+}
+
+// Filter passes only messages to the next Handler for which the predicate returns true
+func Filter(predicate func(message Message) bool) Middleware {
+	return func(next Handler) Handler {
+		return func(message Message) {
+			if predicate(message) {
+				next(message)
+			}
+		}
+	}
+}
+
+// Map passes the message returned by fn to the next Handler
+func Map(fn func(message Message) Message) Middleware {
+	return func(next Handler) Handler {
+		return func(message Message) {
+			mapped := fn(message)
+			if mapped.handlers == nil {
+				mapped.handlers = message.handlers
+			}
+			next(mapped)
+		}
+	}
+}
+
+// Enrich adds the given fields to every message.
+// Existing fields with the same key are overridden.
+func Enrich(fields map[string]interface{}) Middleware {
+	keys := make([]string, 0, len(fields))
+	for key := range fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	enriched := make([]Field, 0, len(keys))
+	for _, key := range keys {
+		enriched = append(enriched, Any(key, fields[key]))
+	}
+
+	index := make(map[string]int, len(enriched))
+	for i, f := range enriched {
+		index[f.Key] = i
+	}
+
+	return Map(func(message Message) Message {
+		// the Fields slice is copied so other copies of the Message are not affected
+		fields := make([]Field, 0, len(message.Fields)+len(enriched))
+		set := make([]bool, len(enriched))
+		for _, f := range message.Fields {
+			if i, ok := index[f.Key]; ok && !set[i] {
+				f = enriched[i]
+				set[i] = true
+			}
+			fields = append(fields, f)
+		}
+		for i, f := range enriched {
+			if !set[i] {
+				fields = append(fields, f)
+			}
+		}
+		message.Fields = fields
+		return message
+	})
+}
+
+// Tee passes every message to the given handlers before passing it to the next Handler
+func Tee(handlers ...Handler) Middleware {
+	return func(next Handler) Handler {
+		return func(message Message) {
+			for _, handler := range handlers {
+				handler(message)
+			}
+			next(message)
+		}
+	}
+}
+
+// dispatch passes the message through the global middleware to the given handlers
+func dispatch(handlers []Handler, message Message) {
+	if middlewareChain == nil {
+		for _, handler := range handlers {
+			handler(message)
+		}
+		return
+	}
+
+	message.handlers = handlers
+	middlewareChain(message)
+}
+
+// deliver is the end of the middleware chain. It redacts the fields added by the middleware
+// and passes the message to its handlers. Messages created by middleware are passed to the handlers
+// of their LogLevel and Logger name.
+func deliver(message Message) {
+	handlers := message.handlers
+	if handlers == nil {
+		handlers = levelConfigFor(message.Name, message.Level).Handlers
+	}
+	message.handlers = nil
+	if redactor != nil {
+		message = redactor.redactMessage(message)
+	}
+
+	for _, handler := range handlers {
+		handler(message)
+	}
+}
@@ -0,0 +1,228 @@
+package log
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// captureMessages replaces the handlers of all LogLevels with a handler collecting the messages.
+// The previous config and middleware are restored after the test.
+func captureMessages(t *testing.T) *[]Message {
+	t.Helper()
+
+	prevConfig, prevMiddleware, prevLevel := config, middleware, logLevel
+	t.Cleanup(func() {
+		stopSummaryTimers(config)
+		config, logLevel = prevConfig, prevLevel
+		SetMiddleware(prevMiddleware)
+	})
+
+	messages := &[]Message{}
+	capture := func(message Message) {
+		*messages = append(*messages, message)
+	}
+
+	cfg := DefaultLevelConfig()
+	for _, lvlCfg := range []*LevelConfig{&cfg.Verbose, &cfg.Debug, &cfg.Info, &cfg.Warn, &cfg.Error, &cfg.Critical} {
+		lvlCfg.SetHandlers([]Handler{capture})
+	}
+	SetLevelConfig(cfg)
+	SetMiddleware(nil)
+	SetLogLevel(VERBOSE)
+
+	return messages
+}
+
+// stopSummaryTimers stops the pending summaries of the rules of the test, so they are not emitted during later tests
+func stopSummaryTimers(cfg *Config) {
+	for _, lvlCfg := range []LevelConfig{cfg.Verbose, cfg.Debug, cfg.Info, cfg.Warn, cfg.Error, cfg.Critical} {
+		if lvlCfg.Sampler != nil {
+			lvlCfg.Sampler.mu.Lock()
+			if lvlCfg.Sampler.timer != nil {
+				lvlCfg.Sampler.timer.Stop()
+			}
+			lvlCfg.Sampler.mu.Unlock()
+		}
+		if lvlCfg.RateLimiter != nil {
+			lvlCfg.RateLimiter.mu.Lock()
+			if lvlCfg.RateLimiter.timer != nil {
+				lvlCfg.RateLimiter.timer.Stop()
+			}
+			lvlCfg.RateLimiter.mu.Unlock()
+		}
+	}
+}
+
+func TestFilter(t *testing.T) {
+	messages := captureMessages(t)
+
+	AddMiddleware(Filter(func(message Message) bool {
+		return !strings.Contains(message.Message, "healthz")
+	}))
+
+	Println(INFO, "GET /healthz")
+	Println(INFO, "GET /users")
+
+	assert.Len(t, *messages, 1)
+	assert.Equal(t, "GET /users\n", (*messages)[0].Message)
+}
+
+func TestMapAndEnrich(t *testing.T) {
+	messages := captureMessages(t)
+
+	AddMiddleware(
+		Enrich(map[string]interface{}{"host": "web-1", "app": "demo"}),
+		Map(func(message Message) Message {
+			message.Message = strings.ToUpper(message.Message)
+			return message
+		}),
+	)
+
+	Println(WARN, "disk full")
+
+	assert.Len(t, *messages, 1)
+	msg := (*messages)[0]
+	assert.Equal(t, "DISK FULL\n", msg.Message)
+	assert.Equal(t, []Field{{Key: "app", value: "demo"}, {Key: "host", value: "web-1"}}, msg.Fields)
+
+	host, ok := msg.Field("host")
+	assert.True(t, ok)
+	assert.Equal(t, "web-1", host)
+}
+
+func TestEnrichOverridesFields(t *testing.T) {
+	messages := captureMessages(t)
+
+	AddMiddleware(Enrich(map[string]interface{}{"host": "web-1", "app": "demo"}))
+
+	Log(INFO, "started", String("host", "local"), Int("port", 80))
+
+	assert.Len(t, *messages, 1)
+	assert.Equal(t, []Field{Any("host", "web-1"), Int("port", 80), Any("app", "demo")}, (*messages)[0].Fields)
+}
+
+func TestMiddlewareChainIsBuiltOnce(t *testing.T) {
+	messages := captureMessages(t)
+
+	built := 0
+	AddMiddleware(func(next Handler) Handler {
+		built++
+		return next
+	})
+
+	for i := 0; i < 3; i++ {
+		Println(INFO, "message", i)
+	}
+
+	assert.Len(t, *messages, 3)
+	assert.Equal(t, 1, built)
+}
+
+func TestMiddlewareFieldsAreRedacted(t *testing.T) {
+	messages := captureMessages(t)
+	prevRedactor := redactor
+	defer SetRedactor(prevRedactor)
+	SetRedactor(DefaultRedactor())
+
+	// messages created by Map reach the handlers, too
+	AddMiddleware(Map(func(message Message) Message {
+		return Message{Level: message.Level, Message: "token=abc123\n"}
+	}))
+
+	Println(INFO, "login")
+
+	assert.Len(t, *messages, 1)
+	assert.NotContains(t, (*messages)[0].Message, "abc123")
+
+	SetMiddleware([]Middleware{Enrich(map[string]interface{}{"password": "hunter2"})})
+	Println(INFO, "login")
+
+	assert.Len(t, *messages, 2)
+	password, _ := (*messages)[1].Field("password")
+	assert.NotEqual(t, "hunter2", password)
+}
+
+func TestMiddlewareMayCreateMessages(t *testing.T) {
+	messages := captureMessages(t)
+
+	AddMiddleware(func(next Handler) Handler {
+		return func(message Message) {
+			next(Message{Level: message.Level, Message: "replaced\n"})
+		}
+	})
+
+	Println(ERROR, "original")
+	assert.Len(t, *messages, 1)
+	assert.Equal(t, "replaced\n", (*messages)[0].Message)
+
+	assert.Contains(t, Sprintln(ERROR, "original"), "[ERROR] replaced\n")
+	assert.Len(t, *messages, 1)
+}
+
+func TestTee(t *testing.T) {
+	messages := captureMessages(t)
+
+	var teed []Message
+	AddMiddleware(Tee(func(message Message) {
+		teed = append(teed, message)
+	}))
+
+	Println(ERROR, "boom")
+
+	assert.Len(t, teed, 1)
+	assert.Len(t, *messages, 1)
+}
+
+func TestChainOrder(t *testing.T) {
+	var order []string
+	mw := func(name string) Middleware {
+		return func(next Handler) Handler {
+			return func(message Message) {
+				order = append(order, name)
+				next(message)
+			}
+		}
+	}
+
+	handler := Chain(func(message Message) {
+		order = append(order, "handler")
+	}, mw("first"), mw("second"))
+	handler(Message{})
+
+	assert.Equal(t, []string{"first", "second", "handler"}, order)
+}
+
+func TestSetFieldDoesNotAlias(t *testing.T) {
+	original := Message{}
+	original.SetField("a", 1)
+
+	cp := original
+	cp.SetField("a", 2)
+	cp.SetField("b", 3)
+
+	a, _ := original.Field("a")
+	assert.Equal(t, 1, a)
+	assert.Len(t, original.Fields, 1)
+}
+
+func TestStringifyFields(t *testing.T) {
+	prevShowTimestamp, prevShowColors := showTimestamp, showColors
+	defer func() {
+		showTimestamp, showColors = prevShowTimestamp, prevShowColors
+	}()
+	ShowTimestamp(false)
+	ShowColors(false)
+
+	msg := Message{
+		Level:   WARN,
+		Message: "request done\n",
+		Fields: []Field{
+			{Key: "status", value: 200},
+			{Key: "agent", value: "curl 8.0"},
+		},
+	}
+
+	assert.Equal(t, "[WARN] request done status=200 agent=\"curl 8.0\"\n", stringify(msg))
+}
@@ -0,0 +1,201 @@
+package log
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// namedMu guards namedLevels and namedConfigs
+var namedMu sync.RWMutex
+
+// namedLevels contains the LogLevels set for named Loggers and their descendants
+var namedLevels = map[string]LogLevel{}
+
+// namedConfigs contains the Configs set for named Loggers and their descendants
+var namedConfigs = map[string]*Config{}
+
+// Named returns a Logger with the given name. The name is added to every message.
+func Named(name string) *Logger {
+	return New().Named(name)
+}
+
+// Named returns a child of the Logger, the name of the child is appended to the name of the Logger
+// separated by a dot, e.g. Named("db").Named("pool") is named db.pool.
+// A child inherits the LogLevel and Config of its parent unless they are overridden for the child.
+func (l *Logger) Named(name string) *Logger {
+	clone := *l
+	if l.name == "" {
+		clone.name = name
+	} else {
+		clone.name = l.name + "." + name
+	}
+	return &clone
+}
+
+// Name returns the name of the Logger
+func (l *Logger) Name() string {
+	return l.name
+}
+
+// SetLogLevel sets the LogLevel of the Logger and its descendants.
+// For an unnamed Logger the global LogLevel is set.
+func (l *Logger) SetLogLevel(level LogLevel) {
+	if l.name == "" {
+		SetLogLevel(level)
+		return
+	}
+	SetNamedLogLevel(l.name, level)
+}
+
+// SetLevelConfig sets the Config of the Logger and its descendants.
+// For an unnamed Logger the global Config is set.
+func (l *Logger) SetLevelConfig(cfg *Config) {
+	if l.name == "" {
+		SetLevelConfig(cfg)
+		return
+	}
+	SetNamedLevelConfig(l.name, cfg)
+}
+
+// SetNamedLogLevel sets the LogLevel of the named Logger and its descendants.
+// The pattern "db.*" is equivalent to "db".
+func SetNamedLogLevel(pattern string, level LogLevel) {
+	namedMu.Lock()
+	defer namedMu.Unlock()
+
+	namedLevels[trimPattern(pattern)] = level
+}
+
+// SetNamedLevelConfig sets the Config of the named Logger and its descendants.
+// Set to nil to inherit the Config of the parent again.
+func SetNamedLevelConfig(name string, cfg *Config) {
+	namedMu.Lock()
+	defer namedMu.Unlock()
+
+	if cfg == nil {
+		delete(namedConfigs, trimPattern(name))
+		return
+	}
+	namedConfigs[trimPattern(name)] = cfg
+}
+
+// SetLogLevels sets the LogLevels of named Loggers from a comma separated list, e.g. "db.*=DEBUG,http=WARN".
+// An entry without name sets the global LogLevel. This is useful if the levels are defined in a config file.
+func SetLogLevels(spec string) error {
+	levels := map[string]LogLevel{}
+	global := LogLevel(0)
+	hasGlobal := false
+
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		name, lvlStr := "", entry
+		if i := strings.LastIndex(entry, "="); i >= 0 {
+			name, lvlStr = strings.TrimSpace(entry[:i]), strings.TrimSpace(entry[i+1:])
+		}
+
+		lvl, ok := level[strings.ToUpper(lvlStr)]
+		if !ok {
+			return fmt.Errorf("LogLevel '%s' of '%s' is not supported", lvlStr, entry)
+		}
+
+		if name == "" || name == "*" {
+			global, hasGlobal = lvl, true
+			continue
+		}
+		levels[name] = lvl
+	}
+
+	if hasGlobal {
+		SetLogLevel(global)
+	}
+	for name, lvl := range levels {
+		SetNamedLogLevel(name, lvl)
+	}
+	return nil
+}
+
+// ResetNamedLevels removes all LogLevels and Configs set for named Loggers
+func ResetNamedLevels() {
+	namedMu.Lock()
+	defer namedMu.Unlock()
+
+	namedLevels = map[string]LogLevel{}
+	namedConfigs = map[string]*Config{}
+}
+
+// trimPattern removes the subtree wildcard of a pattern
+func trimPattern(pattern string) string {
+	return strings.TrimSuffix(pattern, ".*")
+}
+
+// shows returns if messages of the given LogLevel are shown by the Logger.
+// The LogLevel of the context can only make the Logger more verbose than the LogLevel of the named Logger
+// or the global LogLevel, it never hides messages.
+func (l *Logger) shows(level LogLevel) bool {
+	if l.hasLevel && l.level != NONE && level != NONE && l.level >= level {
+		return true
+	}
+	if l.name == "" {
+		return showMe(level)
+	}
+
+	current, ok := namedLogLevel(l.name)
+	if !ok {
+		return showMe(level)
+	}
+	return current != NONE && level != NONE && current >= level
+}
+
+// levelConfig returns the LevelConfig of the given LogLevel for the Logger
+func (l *Logger) levelConfig(level LogLevel) LevelConfig {
+	return levelConfigFor(l.name, level)
+}
+
+// namedLogLevel returns the LogLevel set for the nearest node of the name or its ancestors
+func namedLogLevel(name string) (LogLevel, bool) {
+	namedMu.RLock()
+	defer namedMu.RUnlock()
+
+	if len(namedLevels) == 0 {
+		return 0, false
+	}
+	for node := name; node != ""; node = parentName(node) {
+		if lvl, ok := namedLevels[node]; ok {
+			return lvl, true
+		}
+	}
+	return 0, false
+}
+
+// levelConfigFor returns the LevelConfig of the nearest node of the name or its ancestors with a Config
+// or the global LevelConfig
+func levelConfigFor(name string, level LogLevel) LevelConfig {
+	if name != "" {
+		namedMu.RLock()
+		var cfg *Config
+		if len(namedConfigs) > 0 {
+			for node := name; node != "" && cfg == nil; node = parentName(node) {
+				cfg = namedConfigs[node]
+			}
+		}
+		namedMu.RUnlock()
+
+		if cfg != nil {
+			return cfg.levelConfig(level)
+		}
+	}
+	return getLevelConfig(level)
+}
+
+// parentName returns the name of the parent of a named Logger or an empty string for the root
+func parentName(name string) string {
+	if i := strings.LastIndex(name, "."); i >= 0 {
+		return name[:i]
+	}
+	return ""
+}
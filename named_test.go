@@ -0,0 +1,83 @@
+package log
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNamedLogger(t *testing.T) {
+	messages := captureMessages(t)
+	t.Cleanup(ResetNamedLevels)
+
+	pool := Named("db").Named("pool")
+	assert.Equal(t, "db.pool", pool.Name())
+
+	pool.Println(INFO, "connected")
+	assert.Equal(t, "db.pool", (*messages)[0].Name)
+
+	defer func(colors, timestamp bool) {
+		showColors, showTimestamp = colors, timestamp
+	}(showColors, showTimestamp)
+	ShowColors(false)
+	ShowTimestamp(false)
+	assert.Equal(t, "[INFO][db.pool] connected\n", pool.Sprintln(INFO, "connected"))
+	assert.Contains(t, string(FormatJSON((*messages)[0])), `"logger":"db.pool"`)
+}
+
+func TestNamedLogLevels(t *testing.T) {
+	messages := captureMessages(t)
+	t.Cleanup(ResetNamedLevels)
+	SetLogLevel(INFO)
+
+	assert.NoError(t, SetLogLevels("db.*=DEBUG, http=WARN"))
+
+	db := Named("db")
+	pool := db.Named("pool")
+	http := Named("http")
+	cache := pool.Named("cache")
+	cache.SetLogLevel(ERROR)
+
+	db.Println(DEBUG, "db debug")
+	pool.Println(DEBUG, "pool debug")
+	pool.Println(VERBOSE, "pool verbose")
+	http.Println(INFO, "http info")
+	http.Println(WARN, "http warn")
+	cache.Println(WARN, "cache warn")
+	Println(DEBUG, "root debug")
+	Named("scheduler").Println(INFO, "scheduler info")
+
+	var texts []string
+	for _, message := range *messages {
+		texts = append(texts, message.Message)
+	}
+	assert.Equal(t, []string{"db debug\n", "pool debug\n", "http warn\n", "scheduler info\n"}, texts)
+
+	assert.True(t, pool.Enabled(DEBUG))
+	assert.False(t, http.Enabled(INFO))
+	assert.Error(t, SetLogLevels("db=LOUD"))
+}
+
+func TestNamedLevelConfig(t *testing.T) {
+	messages := captureMessages(t)
+	t.Cleanup(ResetNamedLevels)
+
+	var dbMessages []Message
+	cfg := DefaultLevelConfig()
+	cfg.Info.SetHandlers([]Handler{func(message Message) {
+		dbMessages = append(dbMessages, message)
+	}})
+	Named("db").SetLevelConfig(cfg)
+
+	Named("db").Named("pool").Println(INFO, "inherited")
+	Named("http").Println(INFO, "global")
+
+	assert.Len(t, dbMessages, 1)
+	assert.Equal(t, "inherited\n", dbMessages[0].Message)
+	assert.Len(t, *messages, 1)
+	assert.Equal(t, "global\n", (*messages)[0].Message)
+
+	SetNamedLevelConfig("db", nil)
+	Named("db").Println(INFO, "global again")
+	assert.Len(t, *messages, 2)
+}
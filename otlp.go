@@ -0,0 +1,511 @@
+package log
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// OTLPEncoding defines the payload encoding of the OTLPHandler
+type OTLPEncoding uint
+
+const (
+	// OTLPJSON encodes the logs as OTLP/HTTP JSON
+	OTLPJSON OTLPEncoding = iota
+	// OTLPProtobuf encodes the logs as OTLP/HTTP binary protobuf
+	OTLPProtobuf
+)
+
+// otlpScopeName is the instrumentation scope of messages of the unnamed Loggers.
+// Messages of named Loggers use the name of the Logger as scope.
+const otlpScopeName = "github.com/chris-dot-exe/AwesomeLog"
+
+// maxOTLPBackoff is the maximum delay between two retries of an export
+const maxOTLPBackoff = 30 * time.Second
+
+// OTLPConfig is the configuration of the OTLPHandler
+type OTLPConfig struct {
+	// Endpoint is the URL of the logs endpoint of the collector, default is http://localhost:4318/v1/logs
+	Endpoint string
+	Encoding OTLPEncoding
+	// Headers are added to every export request, e.g. for authentication
+	Headers map[string]string
+	// Resource contains the resource attributes, e.g. service.name
+	Resource map[string]string
+	// BatchSize is the maximum number of messages per export request, default is 512
+	BatchSize int
+	// FlushInterval is the maximum time a message waits for its batch to be exported, default is 1 second
+	FlushInterval time.Duration
+	// MaxQueueSize is the maximum number of messages waiting for export, default is 2048.
+	// Messages are dropped while the queue is full.
+	MaxQueueSize int
+	// MaxRetries is the number of retries of a failed export, default is 5. Set to -1 to disable retries.
+	MaxRetries int
+	// RetryBackoff is the delay before the first retry, it doubles with every retry up to 30 seconds.
+	// Default is 1 second. A Retry-After header of the collector takes precedence.
+	RetryBackoff time.Duration
+	// Client sends the export requests, default is a http.Client with a timeout of 10 seconds
+	Client *http.Client
+	// OnError is called with the error of every export which failed after all retries.
+	// It must not log through AwesomeLog at levels exported by the handler.
+	OnError func(err error)
+}
+
+// OTLPHandler exports messages in batches to an OpenTelemetry collector using OTLP/HTTP.
+// Add the Handler to the LevelConfig of every level which should be exported
+// and Close the OTLPHandler before the program exits.
+type OTLPHandler struct {
+	cfg OTLPConfig
+
+	mu      sync.Mutex
+	pending []Message
+	closed  bool
+	dropped uint64
+
+	// exportMu serializes the exports so batches are sent in order
+	exportMu sync.Mutex
+
+	full      chan struct{}
+	done      chan struct{}
+	stopped   chan struct{}
+	closeOnce sync.Once
+}
+
+// NewOTLPHandler returns an OTLPHandler and starts exporting in the background
+func NewOTLPHandler(cfg OTLPConfig) *OTLPHandler {
+	if cfg.Endpoint == "" {
+		cfg.Endpoint = "http://localhost:4318/v1/logs"
+	}
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = 512
+	}
+	if cfg.FlushInterval <= 0 {
+		cfg.FlushInterval = time.Second
+	}
+	if cfg.MaxQueueSize <= 0 {
+		cfg.MaxQueueSize = 2048
+	}
+	if cfg.MaxRetries == 0 {
+		cfg.MaxRetries = 5
+	}
+	if cfg.RetryBackoff <= 0 {
+		cfg.RetryBackoff = time.Second
+	}
+	if cfg.Client == nil {
+		cfg.Client = &http.Client{Timeout: 10 * time.Second}
+	}
+
+	h := &OTLPHandler{
+		cfg:     cfg,
+		full:    make(chan struct{}, 1),
+		done:    make(chan struct{}),
+		stopped: make(chan struct{}),
+	}
+	go h.run()
+	return h
+}
+
+// Handler returns a Handler queueing every message for export
+func (h *OTLPHandler) Handler() Handler {
+	return h.enqueue
+}
+
+// Dropped returns the number of messages dropped because the queue was full or the OTLPHandler was closed
+func (h *OTLPHandler) Dropped() uint64 {
+	return atomic.LoadUint64(&h.dropped)
+}
+
+// Flush exports all queued messages and returns the error of the last failed export
+func (h *OTLPHandler) Flush() error {
+	h.exportMu.Lock()
+	defer h.exportMu.Unlock()
+
+	var err error
+	for {
+		h.mu.Lock()
+		n := len(h.pending)
+		if n > h.cfg.BatchSize {
+			n = h.cfg.BatchSize
+		}
+		batch := h.pending[:n:n]
+		h.pending = h.pending[n:]
+		h.mu.Unlock()
+
+		if len(batch) == 0 {
+			return err
+		}
+		if exportErr := h.export(batch); exportErr != nil {
+			err = exportErr
+		}
+	}
+}
+
+// Close stops the background export and exports the queued messages.
+// Messages passed to the Handler after Close are dropped.
+func (h *OTLPHandler) Close() error {
+	h.closeOnce.Do(func() {
+		h.mu.Lock()
+		h.closed = true
+		h.mu.Unlock()
+
+		close(h.done)
+		<-h.stopped
+	})
+	return h.Flush()
+}
+
+func (h *OTLPHandler) enqueue(message Message) {
+	h.mu.Lock()
+	if h.closed || len(h.pending) >= h.cfg.MaxQueueSize {
+		h.mu.Unlock()
+		atomic.AddUint64(&h.dropped, 1)
+		return
+	}
+	h.pending = append(h.pending, message)
+	full := len(h.pending) >= h.cfg.BatchSize
+	h.mu.Unlock()
+
+	if full {
+		select {
+		case h.full <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// run exports the queued messages every FlushInterval or as soon as a batch is full
+func (h *OTLPHandler) run() {
+	defer close(h.stopped)
+
+	ticker := time.NewTicker(h.cfg.FlushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+		case <-h.full:
+		case <-h.done:
+			return
+		}
+		if err := h.Flush(); err != nil && h.cfg.OnError != nil {
+			h.cfg.OnError(err)
+		}
+	}
+}
+
+// export sends the batch to the collector and retries failed requests with exponential backoff
+func (h *OTLPHandler) export(batch []Message) error {
+	request := newOTLPRequest(batch, h.cfg.Resource)
+
+	var body []byte
+	contentType := "application/json"
+	if h.cfg.Encoding == OTLPProtobuf {
+		body = request.marshalProto()
+		contentType = "application/x-protobuf"
+	} else {
+		var err error
+		if body, err = json.Marshal(request); err != nil {
+			return err
+		}
+	}
+
+	backoff := h.cfg.RetryBackoff
+	for attempt := 0; ; attempt++ {
+		retryAfter, err := h.send(body, contentType)
+		if err == nil {
+			return nil
+		}
+
+		var permanent *otlpPermanentError
+		if errors.As(err, &permanent) || attempt >= h.cfg.MaxRetries {
+			return err
+		}
+
+		delay := backoff
+		if retryAfter > 0 {
+			delay = retryAfter
+		}
+		time.Sleep(delay)
+
+		backoff *= 2
+		if backoff > maxOTLPBackoff {
+			backoff = maxOTLPBackoff
+		}
+	}
+}
+
+// otlpPermanentError is returned for responses which must not be retried
+type otlpPermanentError struct {
+	err error
+}
+
+func (e *otlpPermanentError) Error() string {
+	return e.err.Error()
+}
+
+func (e *otlpPermanentError) Unwrap() error {
+	return e.err
+}
+
+// send posts the body to the collector and returns the delay requested by a Retry-After header
+func (h *OTLPHandler) send(body []byte, contentType string) (time.Duration, error) {
+	r, err := http.NewRequest(http.MethodPost, h.cfg.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return 0, &otlpPermanentError{err}
+	}
+	r.Header.Set("Content-Type", contentType)
+	for key, value := range h.cfg.Headers {
+		r.Header.Set(key, value)
+	}
+
+	resp, err := h.cfg.Client.Do(r)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		_, _ = io.Copy(ioutil.Discard, resp.Body)
+		return 0, nil
+	}
+
+	message, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 1024))
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		var retryAfter time.Duration
+		if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && seconds > 0 {
+			retryAfter = time.Duration(seconds) * time.Second
+		}
+		return retryAfter, fmt.Errorf("otlp export failed with status %d: %s", resp.StatusCode, message)
+	}
+	return 0, &otlpPermanentError{fmt.Errorf("otlp export failed with status %d: %s", resp.StatusCode, message)}
+}
+
+// otlpSeverity returns the OpenTelemetry severity number of the LogLevel
+func otlpSeverity(level LogLevel) int {
+	switch level {
+	case VERBOSE:
+		return 1 // TRACE
+	case DEBUG:
+		return 5 // DEBUG
+	case INFO:
+		return 9 // INFO
+	case WARN:
+		return 13 // WARN
+	case ERROR:
+		return 17 // ERROR
+	case CRITICAL:
+		return 21 // FATAL
+	}
+	return 0 // UNSPECIFIED
+}
+
+// otlpRequest is an ExportLogsServiceRequest, the JSON tags follow the OTLP/HTTP JSON encoding
+type otlpRequest struct {
+	ResourceLogs []otlpResourceLogs `json:"resourceLogs"`
+}
+
+type otlpResourceLogs struct {
+	Resource  otlpResource    `json:"resource"`
+	ScopeLogs []otlpScopeLogs `json:"scopeLogs"`
+}
+
+type otlpResource struct {
+	Attributes []otlpKeyValue `json:"attributes,omitempty"`
+}
+
+type otlpScopeLogs struct {
+	Scope      otlpScope       `json:"scope"`
+	LogRecords []otlpLogRecord `json:"logRecords"`
+}
+
+type otlpScope struct {
+	Name string `json:"name,omitempty"`
+}
+
+type otlpLogRecord struct {
+	TimeUnixNano         uint64         `json:"timeUnixNano,string"`
+	ObservedTimeUnixNano uint64         `json:"observedTimeUnixNano,string"`
+	SeverityNumber       int            `json:"severityNumber"`
+	SeverityText         string         `json:"severityText"`
+	Body                 otlpValue      `json:"body"`
+	Attributes           []otlpKeyValue `json:"attributes,omitempty"`
+	// TraceID and SpanID are hex encoded
+	TraceID string `json:"traceId,omitempty"`
+	SpanID  string `json:"spanId,omitempty"`
+}
+
+type otlpKeyValue struct {
+	Key   string    `json:"key"`
+	Value otlpValue `json:"value"`
+}
+
+// otlpValue is an AnyValue holding a string, int64, float64 or bool
+type otlpValue struct {
+	value interface{}
+}
+
+// MarshalJSON encodes the value as AnyValue, 64 bit integers are encoded as string
+func (v otlpValue) MarshalJSON() ([]byte, error) {
+	switch value := v.value.(type) {
+	case int64:
+		return json.Marshal(map[string]string{"intValue": strconv.FormatInt(value, 10)})
+	case float64:
+		if math.IsInf(value, 0) || math.IsNaN(value) {
+			name := "NaN"
+			if math.IsInf(value, 1) {
+				name = "Infinity"
+			} else if math.IsInf(value, -1) {
+				name = "-Infinity"
+			}
+			return json.Marshal(map[string]string{"doubleValue": name})
+		}
+		return json.Marshal(map[string]float64{"doubleValue": value})
+	case bool:
+		return json.Marshal(map[string]bool{"boolValue": value})
+	case string:
+		return json.Marshal(map[string]string{"stringValue": value})
+	}
+	return []byte("{}"), nil
+}
+
+// newOTLPRequest converts the messages to log records grouped by the name of the Logger
+func newOTLPRequest(messages []Message, resource map[string]string) otlpRequest {
+	keys := make([]string, 0, len(resource))
+	for key := range resource {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	resourceLogs := otlpResourceLogs{}
+	for _, key := range keys {
+		resourceLogs.Resource.Attributes = append(resourceLogs.Resource.Attributes, otlpKeyValue{Key: key, Value: otlpValue{resource[key]}})
+	}
+
+	observed := uint64(time.Now().UnixNano())
+	scopes := map[string]int{}
+	for _, message := range messages {
+		name := message.Name
+		if name == "" {
+			name = otlpScopeName
+		}
+		i, ok := scopes[name]
+		if !ok {
+			i = len(resourceLogs.ScopeLogs)
+			scopes[name] = i
+			resourceLogs.ScopeLogs = append(resourceLogs.ScopeLogs, otlpScopeLogs{Scope: otlpScope{Name: name}})
+		}
+		resourceLogs.ScopeLogs[i].LogRecords = append(resourceLogs.ScopeLogs[i].LogRecords, newOTLPLogRecord(message, observed))
+	}
+
+	return otlpRequest{ResourceLogs: []otlpResourceLogs{resourceLogs}}
+}
+
+func newOTLPLogRecord(message Message, observed uint64) otlpLogRecord {
+	record := otlpLogRecord{
+		TimeUnixNano:         uint64(message.Time.UnixNano()),
+		ObservedTimeUnixNano: observed,
+		SeverityNumber:       otlpSeverity(message.Level),
+		SeverityText:         message.Level.String(),
+		Body:                 otlpValue{strings.TrimSuffix(message.Message, "\n")},
+	}
+
+	if message.Caller != (Caller{}) {
+		path, function := message.Caller.File, message.Caller.Function
+		if path == "" {
+			path = message.Caller.Path
+		}
+		if function == "" {
+			function = message.Caller.FunctionName
+		}
+		record.Attributes = append(record.Attributes,
+			otlpKeyValue{Key: "code.filepath", Value: otlpValue{path}},
+			otlpKeyValue{Key: "code.function", Value: otlpValue{function}},
+			otlpKeyValue{Key: "code.lineno", Value: otlpValue{int64(message.Caller.LineNumber)}},
+		)
+	}
+
+	for _, f := range message.Fields {
+		switch {
+		case f.Key == "trace_id" && f.kind == stringField && isHex(f.str, 32):
+			record.TraceID = f.str
+		case f.Key == "span_id" && f.kind == stringField && isHex(f.str, 16):
+			record.SpanID = f.str
+		default:
+			if value, ok := otlpFieldValue(f); ok {
+				record.Attributes = append(record.Attributes, otlpKeyValue{Key: f.Key, Value: otlpValue{value}})
+			}
+		}
+	}
+
+	if len(message.Errors) > 0 {
+		err := message.Errors[0]
+		record.Attributes = append(record.Attributes,
+			otlpKeyValue{Key: "exception.type", Value: otlpValue{err.Type}},
+			otlpKeyValue{Key: "exception.message", Value: otlpValue{err.Message}},
+		)
+	}
+
+	stack := message.Stack
+	if len(stack) == 0 && len(message.Errors) > 0 {
+		stack = message.Errors[0].Stack
+	}
+	if len(stack) > 0 {
+		record.Attributes = append(record.Attributes,
+			otlpKeyValue{Key: "exception.stacktrace", Value: otlpValue{strings.TrimPrefix(appendStack("", stack), "\n")}})
+	}
+
+	return record
+}
+
+// otlpFieldValue converts the value of the Field to a string, int64, float64 or bool.
+// Structs, maps and slices are encoded as JSON string.
+func otlpFieldValue(f Field) (interface{}, bool) {
+	switch f.kind {
+	case intField, int64Field:
+		return f.integer, true
+	case floatField:
+		return math.Float64frombits(uint64(f.integer)), true
+	case boolField:
+		return f.integer == 1, true
+	}
+
+	switch value := f.encodeJSON().(type) {
+	case nil:
+		return nil, false
+	case string:
+		return value, true
+	case bool:
+		return value, true
+	case json.Number:
+		if i, err := value.Int64(); err == nil {
+			return i, true
+		}
+		if float, err := value.Float64(); err == nil {
+			return float, true
+		}
+		return value.String(), true
+	default:
+		var sb strings.Builder
+		_ = writePrettyJSON(&sb, value, "", 0)
+		return sb.String(), true
+	}
+}
+
+// otlpID decodes a hex encoded trace or span ID
+func otlpID(id string) []byte {
+	b, _ := hex.DecodeString(id)
+	return b
+}
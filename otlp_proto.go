@@ -0,0 +1,162 @@
+package log
+
+import (
+	"encoding/binary"
+	"math"
+)
+
+// protobuf wire types
+const (
+	wireVarint  = 0
+	wireFixed64 = 1
+	wireBytes   = 2
+)
+
+// protoBuffer encodes protobuf messages without generated code.
+// Only the wire types used by the OTLP logs messages are supported.
+type protoBuffer struct {
+	b []byte
+}
+
+func (p *protoBuffer) tag(field int, wireType int) {
+	p.varint(uint64(field)<<3 | uint64(wireType))
+}
+
+func (p *protoBuffer) varint(v uint64) {
+	for v >= 0x80 {
+		p.b = append(p.b, byte(v)|0x80)
+		v >>= 7
+	}
+	p.b = append(p.b, byte(v))
+}
+
+// uint writes a varint field, zero values are omitted like in proto3
+func (p *protoBuffer) uint(field int, v uint64) {
+	if v == 0 {
+		return
+	}
+	p.tag(field, wireVarint)
+	p.varint(v)
+}
+
+// fixed64 writes a fixed64 field, zero values are omitted like in proto3
+func (p *protoBuffer) fixed64(field int, v uint64) {
+	if v == 0 {
+		return
+	}
+	p.tag(field, wireFixed64)
+	p.fixed(v)
+}
+
+func (p *protoBuffer) fixed(v uint64) {
+	var b [8]byte
+	binary.LittleEndian.PutUint64(b[:], v)
+	p.b = append(p.b, b[:]...)
+}
+
+// bytes writes a length delimited field, empty values are omitted like in proto3
+func (p *protoBuffer) bytes(field int, b []byte) {
+	if len(b) == 0 {
+		return
+	}
+	p.tag(field, wireBytes)
+	p.varint(uint64(len(b)))
+	p.b = append(p.b, b...)
+}
+
+func (p *protoBuffer) string(field int, s string) {
+	if s == "" {
+		return
+	}
+	p.tag(field, wireBytes)
+	p.varint(uint64(len(s)))
+	p.b = append(p.b, s...)
+}
+
+// message writes the embedded message encoded by encode. Empty messages are written, too.
+func (p *protoBuffer) message(field int, encode func(p *protoBuffer)) {
+	var embedded protoBuffer
+	encode(&embedded)
+	p.tag(field, wireBytes)
+	p.varint(uint64(len(embedded.b)))
+	p.b = append(p.b, embedded.b...)
+}
+
+// marshalProto encodes the request as opentelemetry.proto.collector.logs.v1.ExportLogsServiceRequest
+func (r otlpRequest) marshalProto() []byte {
+	var p protoBuffer
+	for _, resourceLogs := range r.ResourceLogs {
+		resourceLogs := resourceLogs
+		p.message(1, resourceLogs.marshalProto)
+	}
+	return p.b
+}
+
+// marshalProto encodes opentelemetry.proto.logs.v1.ResourceLogs
+func (r otlpResourceLogs) marshalProto(p *protoBuffer) {
+	p.message(1, func(p *protoBuffer) {
+		marshalProtoAttributes(p, 1, r.Resource.Attributes)
+	})
+	for _, scopeLogs := range r.ScopeLogs {
+		scopeLogs := scopeLogs
+		p.message(2, scopeLogs.marshalProto)
+	}
+}
+
+// marshalProto encodes opentelemetry.proto.logs.v1.ScopeLogs
+func (s otlpScopeLogs) marshalProto(p *protoBuffer) {
+	p.message(1, func(p *protoBuffer) {
+		p.string(1, s.Scope.Name)
+	})
+	for _, record := range s.LogRecords {
+		record := record
+		p.message(2, record.marshalProto)
+	}
+}
+
+// marshalProto encodes opentelemetry.proto.logs.v1.LogRecord
+func (r otlpLogRecord) marshalProto(p *protoBuffer) {
+	p.fixed64(1, r.TimeUnixNano)
+	p.uint(2, uint64(r.SeverityNumber))
+	p.string(3, r.SeverityText)
+	p.message(5, r.Body.marshalProto)
+	marshalProtoAttributes(p, 6, r.Attributes)
+	p.bytes(9, otlpID(r.TraceID))
+	p.bytes(10, otlpID(r.SpanID))
+	p.fixed64(11, r.ObservedTimeUnixNano)
+}
+
+// marshalProto encodes opentelemetry.proto.common.v1.AnyValue
+func (v otlpValue) marshalProto(p *protoBuffer) {
+	// the fields of the oneof are written even if they contain the zero value
+	switch value := v.value.(type) {
+	case string:
+		p.tag(1, wireBytes)
+		p.varint(uint64(len(value)))
+		p.b = append(p.b, value...)
+	case bool:
+		p.tag(2, wireVarint)
+		if value {
+			p.varint(1)
+		} else {
+			p.varint(0)
+		}
+	case int64:
+		p.tag(3, wireVarint)
+		p.varint(uint64(value))
+	case float64:
+		p.tag(4, wireFixed64)
+		p.fixed(math.Float64bits(value))
+	}
+}
+
+// marshalProtoAttributes encodes the attributes as repeated opentelemetry.proto.common.v1.KeyValue
+func marshalProtoAttributes(p *protoBuffer, field int, attributes []otlpKeyValue) {
+	for _, attribute := range attributes {
+		attribute := attribute
+		p.message(field, func(p *protoBuffer) {
+			p.string(1, attribute.Key)
+			p.message(2, attribute.Value.marshalProto)
+		})
+	}
+}
@@ -0,0 +1,299 @@
+package log
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// collector is a stand-in for an OpenTelemetry collector recording the export requests
+type collector struct {
+	mu       sync.Mutex
+	requests []*http.Request
+	bodies   [][]byte
+	statuses []int
+	received chan struct{}
+}
+
+func newCollector(t *testing.T, statuses ...int) (*collector, *httptest.Server) {
+	c := &collector{statuses: statuses, received: make(chan struct{}, 100)}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+
+		c.mu.Lock()
+		c.requests = append(c.requests, r)
+		c.bodies = append(c.bodies, body)
+		status := http.StatusOK
+		if len(c.statuses) > 0 {
+			status, c.statuses = c.statuses[0], c.statuses[1:]
+		}
+		c.mu.Unlock()
+
+		w.WriteHeader(status)
+		c.received <- struct{}{}
+	}))
+	t.Cleanup(server.Close)
+	return c, server
+}
+
+func (c *collector) count() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.requests)
+}
+
+func otlpTestMessage() Message {
+	return Message{
+		Time:    time.Unix(1644831164, 5),
+		Level:   WARN,
+		Name:    "db.pool",
+		Caller:  Caller{Path: "pool.go", FunctionName: "Get", LineNumber: 42, Function: "github.com/org/app/db.(*Pool).Get", File: "/src/app/db/pool.go"},
+		Message: "connection slow\n",
+		Fields: []Field{
+			String("trace_id", "4bf92f3577b34da6a3ce929d0e0e4736"),
+			String("span_id", "00f067aa0ba902b7"),
+			Int("attempt", 3),
+			Float("ratio", 0.5),
+			Bool("retry", true),
+			Duration("took", 1500*time.Millisecond),
+			Any("tags", []string{"a", "b"}),
+		},
+		Errors: []ErrorInfo{{Message: "timeout", Type: "*errors.errorString"}},
+	}
+}
+
+func TestOTLPHandlerJSON(t *testing.T) {
+	c, server := newCollector(t)
+	h := NewOTLPHandler(OTLPConfig{
+		Endpoint: server.URL,
+		Headers:  map[string]string{"Authorization": "Bearer token"},
+		Resource: map[string]string{"service.name": "api", "deployment.environment": "test"},
+	})
+	defer h.Close()
+
+	h.Handler()(otlpTestMessage())
+	h.Handler()(Message{Time: time.Unix(1, 0), Level: CRITICAL, Message: "down"})
+	assert.NoError(t, h.Flush())
+
+	assert.Equal(t, 1, c.count())
+	assert.Equal(t, "application/json", c.requests[0].Header.Get("Content-Type"))
+	assert.Equal(t, "Bearer token", c.requests[0].Header.Get("Authorization"))
+
+	var request struct {
+		ResourceLogs []struct {
+			Resource struct {
+				Attributes []map[string]interface{}
+			}
+			ScopeLogs []struct {
+				Scope      map[string]string
+				LogRecords []map[string]interface{}
+			}
+		}
+	}
+	assert.NoError(t, json.Unmarshal(c.bodies[0], &request))
+
+	resourceLogs := request.ResourceLogs[0]
+	assert.Equal(t, []map[string]interface{}{
+		{"key": "deployment.environment", "value": map[string]interface{}{"stringValue": "test"}},
+		{"key": "service.name", "value": map[string]interface{}{"stringValue": "api"}},
+	}, resourceLogs.Resource.Attributes)
+
+	assert.Len(t, resourceLogs.ScopeLogs, 2)
+	assert.Equal(t, "db.pool", resourceLogs.ScopeLogs[0].Scope["name"])
+	assert.Equal(t, otlpScopeName, resourceLogs.ScopeLogs[1].Scope["name"])
+
+	record := resourceLogs.ScopeLogs[0].LogRecords[0]
+	assert.Equal(t, "1644831164000000005", record["timeUnixNano"])
+	assert.Equal(t, float64(13), record["severityNumber"])
+	assert.Equal(t, "WARN", record["severityText"])
+	assert.Equal(t, map[string]interface{}{"stringValue": "connection slow"}, record["body"])
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", record["traceId"])
+	assert.Equal(t, "00f067aa0ba902b7", record["spanId"])
+
+	attributes := map[string]interface{}{}
+	for _, attribute := range record["attributes"].([]interface{}) {
+		kv := attribute.(map[string]interface{})
+		attributes[kv["key"].(string)] = kv["value"]
+	}
+	assert.Equal(t, map[string]interface{}{
+		"code.filepath":     map[string]interface{}{"stringValue": "/src/app/db/pool.go"},
+		"code.function":     map[string]interface{}{"stringValue": "github.com/org/app/db.(*Pool).Get"},
+		"code.lineno":       map[string]interface{}{"intValue": "42"},
+		"attempt":           map[string]interface{}{"intValue": "3"},
+		"ratio":             map[string]interface{}{"doubleValue": 0.5},
+		"retry":             map[string]interface{}{"boolValue": true},
+		"took":              map[string]interface{}{"stringValue": "1.5s"},
+		"tags":              map[string]interface{}{"stringValue": `["a","b"]`},
+		"exception.type":    map[string]interface{}{"stringValue": "*errors.errorString"},
+		"exception.message": map[string]interface{}{"stringValue": "timeout"},
+	}, attributes)
+
+	critical := resourceLogs.ScopeLogs[1].LogRecords[0]
+	assert.Equal(t, float64(21), critical["severityNumber"])
+	assert.Nil(t, critical["attributes"])
+}
+
+func TestOTLPHandlerProtobuf(t *testing.T) {
+	c, server := newCollector(t)
+	h := NewOTLPHandler(OTLPConfig{Endpoint: server.URL, Encoding: OTLPProtobuf, Resource: map[string]string{"service.name": "api"}})
+	defer h.Close()
+
+	h.Handler()(otlpTestMessage())
+	assert.NoError(t, h.Flush())
+	assert.Equal(t, "application/x-protobuf", c.requests[0].Header.Get("Content-Type"))
+
+	// ExportLogsServiceRequest.resource_logs
+	resourceLogs := decodeProto(t, decodeProto(t, c.bodies[0])[1][0].([]byte))
+	resource := decodeProto(t, resourceLogs[1][0].([]byte))
+	attribute := decodeProto(t, resource[1][0].([]byte))
+	assert.Equal(t, "service.name", string(attribute[1][0].([]byte)))
+	assert.Equal(t, "api", string(decodeProto(t, attribute[2][0].([]byte))[1][0].([]byte)))
+
+	scopeLogs := decodeProto(t, resourceLogs[2][0].([]byte))
+	assert.Equal(t, "db.pool", string(decodeProto(t, scopeLogs[1][0].([]byte))[1][0].([]byte)))
+
+	record := decodeProto(t, scopeLogs[2][0].([]byte))
+	assert.Equal(t, uint64(1644831164000000005), record[1][0])
+	assert.Equal(t, uint64(13), record[2][0])
+	assert.Equal(t, "WARN", string(record[3][0].([]byte)))
+	assert.Equal(t, "connection slow", string(decodeProto(t, record[5][0].([]byte))[1][0].([]byte)))
+	assert.Equal(t, otlpID("4bf92f3577b34da6a3ce929d0e0e4736"), record[9][0])
+	assert.Equal(t, otlpID("00f067aa0ba902b7"), record[10][0])
+
+	attributes := map[string]map[int][]interface{}{}
+	for _, raw := range record[6] {
+		kv := decodeProto(t, raw.([]byte))
+		attributes[string(kv[1][0].([]byte))] = decodeProto(t, kv[2][0].([]byte))
+	}
+	assert.Equal(t, "/src/app/db/pool.go", string(attributes["code.filepath"][1][0].([]byte)))
+	assert.Equal(t, uint64(42), attributes["code.lineno"][3][0])
+	assert.Equal(t, uint64(1), attributes["retry"][2][0])
+	assert.Equal(t, uint64(0x3fe0000000000000), attributes["ratio"][4][0])
+}
+
+// decodeProto decodes the fields of a protobuf message. Varint and fixed64 values are returned as uint64,
+// length delimited values as []byte.
+func decodeProto(t *testing.T, b []byte) map[int][]interface{} {
+	fields := map[int][]interface{}{}
+	for len(b) > 0 {
+		key, n := binary.Uvarint(b)
+		assert.Greater(t, n, 0)
+		b = b[n:]
+
+		field := int(key >> 3)
+		switch key & 7 {
+		case wireVarint:
+			v, n := binary.Uvarint(b)
+			assert.Greater(t, n, 0)
+			fields[field] = append(fields[field], v)
+			b = b[n:]
+		case wireFixed64:
+			fields[field] = append(fields[field], binary.LittleEndian.Uint64(b))
+			b = b[8:]
+		case wireBytes:
+			length, n := binary.Uvarint(b)
+			assert.Greater(t, n, 0)
+			b = b[n:]
+			fields[field] = append(fields[field], b[:length])
+			b = b[length:]
+		default:
+			t.Fatalf("unexpected wire type %d", key&7)
+		}
+	}
+	return fields
+}
+
+func TestOTLPHandlerRetries(t *testing.T) {
+	c, server := newCollector(t, http.StatusServiceUnavailable, http.StatusTooManyRequests, http.StatusOK)
+	h := NewOTLPHandler(OTLPConfig{Endpoint: server.URL, RetryBackoff: time.Millisecond})
+	defer h.Close()
+
+	h.Handler()(otlpTestMessage())
+	assert.NoError(t, h.Flush())
+	assert.Equal(t, 3, c.count())
+	assert.Equal(t, c.bodies[0], c.bodies[2])
+}
+
+func TestOTLPHandlerGivesUp(t *testing.T) {
+	c, server := newCollector(t, http.StatusBadRequest)
+	h := NewOTLPHandler(OTLPConfig{Endpoint: server.URL, RetryBackoff: time.Millisecond})
+	defer h.Close()
+
+	h.Handler()(otlpTestMessage())
+	assert.Error(t, h.Flush())
+	assert.Equal(t, 1, c.count())
+
+	c, server = newCollector(t, http.StatusBadGateway, http.StatusBadGateway, http.StatusBadGateway)
+	h = NewOTLPHandler(OTLPConfig{Endpoint: server.URL, RetryBackoff: time.Millisecond, MaxRetries: 2})
+	defer h.Close()
+
+	h.Handler()(otlpTestMessage())
+	assert.Error(t, h.Flush())
+	assert.Equal(t, 3, c.count())
+}
+
+func TestOTLPHandlerBatches(t *testing.T) {
+	c, server := newCollector(t)
+	h := NewOTLPHandler(OTLPConfig{Endpoint: server.URL, BatchSize: 2, FlushInterval: time.Hour})
+
+	h.Handler()(otlpTestMessage())
+	h.Handler()(otlpTestMessage())
+	select {
+	case <-c.received:
+	case <-time.After(5 * time.Second):
+		t.Fatal("full batch was not exported")
+	}
+
+	h.Handler()(otlpTestMessage())
+	assert.NoError(t, h.Close())
+	assert.Equal(t, 2, c.count())
+
+	h.Handler()(otlpTestMessage())
+	assert.Equal(t, uint64(1), h.Dropped())
+}
+
+func TestOTLPHandlerFlushInterval(t *testing.T) {
+	c, server := newCollector(t)
+	h := NewOTLPHandler(OTLPConfig{Endpoint: server.URL, FlushInterval: 10 * time.Millisecond})
+	defer h.Close()
+
+	h.Handler()(otlpTestMessage())
+	select {
+	case <-c.received:
+	case <-time.After(5 * time.Second):
+		t.Fatal("message was not exported")
+	}
+}
+
+func TestOTLPHandlerOnError(t *testing.T) {
+	_, server := newCollector(t, http.StatusBadRequest)
+	errs := make(chan error, 1)
+	h := NewOTLPHandler(OTLPConfig{Endpoint: server.URL, FlushInterval: 10 * time.Millisecond, OnError: func(err error) { errs <- err }})
+	defer h.Close()
+
+	h.Handler()(otlpTestMessage())
+	select {
+	case err := <-errs:
+		var permanent *otlpPermanentError
+		assert.True(t, errors.As(err, &permanent))
+	case <-time.After(5 * time.Second):
+		t.Fatal("error was not reported")
+	}
+}
+
+func TestOTLPSeverity(t *testing.T) {
+	assert.Equal(t, 1, otlpSeverity(VERBOSE))
+	assert.Equal(t, 5, otlpSeverity(DEBUG))
+	assert.Equal(t, 9, otlpSeverity(INFO))
+	assert.Equal(t, 13, otlpSeverity(WARN))
+	assert.Equal(t, 17, otlpSeverity(ERROR))
+	assert.Equal(t, 21, otlpSeverity(CRITICAL))
+}
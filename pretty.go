@@ -0,0 +1,400 @@
+package log
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// prettyObject is an object with ordered keys used to render structs and maps
+type prettyObject []prettyEntry
+
+type prettyEntry struct {
+	Key   string
+	Value interface{}
+}
+
+// logTag contains the parsed options of a `log:"..."` struct tag
+type logTag struct {
+	skip      bool
+	redact    bool
+	omitEmpty bool
+	name      string
+}
+
+var (
+	jsonNumberType    = reflect.TypeOf(json.Number(""))
+	jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// parseLogTag parses the log tag of a struct field.
+// Supported options are "-", "redact", "omitempty" and "name=...", separated by commas.
+func parseLogTag(field reflect.StructField) logTag {
+	tag := logTag{}
+
+	value, ok := field.Tag.Lookup("log")
+	if !ok {
+		return tag
+	}
+	if value == "-" {
+		tag.skip = true
+		return tag
+	}
+
+	for _, option := range strings.Split(value, ",") {
+		option = strings.TrimSpace(option)
+		switch {
+		case option == "redact":
+			tag.redact = true
+		case option == "omitempty":
+			tag.omitEmpty = true
+		case strings.HasPrefix(option, "name="):
+			tag.name = strings.TrimPrefix(option, "name=")
+		}
+	}
+	return tag
+}
+
+// fieldName returns the name of a struct field used in log output.
+// The log tag has precedence over the json tag, the field name is used as fallback.
+func fieldName(field reflect.StructField, tag logTag) string {
+	if tag.name != "" {
+		return tag.name
+	}
+	if jsonTag, ok := field.Tag.Lookup("json"); ok {
+		name := strings.Split(jsonTag, ",")[0]
+		if name != "" && name != "-" {
+			return name
+		}
+	}
+	return field.Name
+}
+
+// visitKey identifies a pointer, map or slice on the current path to detect cycles.
+// Slices are identified by their data pointer and length like in encoding/json,
+// because a slice and its subslices share the data pointer.
+type visitKey struct {
+	ptr uintptr
+	len int
+}
+
+// toPretty converts a value to a tree of prettyObject, []interface{} and scalar values
+// honoring the log struct tags. JSON tags only affect the field names.
+func toPretty(value interface{}) (interface{}, error) {
+	return prettyValue(reflect.ValueOf(value), map[visitKey]bool{})
+}
+
+func prettyValue(v reflect.Value, visited map[visitKey]bool) (interface{}, error) {
+	if !v.IsValid() {
+		return nil, nil
+	}
+
+	if (v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface) && v.IsNil() {
+		return nil, nil
+	}
+
+	if v.Type() == jsonNumberType {
+		return v.Interface(), nil
+	}
+	if v.Type().Implements(jsonMarshalerType) {
+		return prettyFromMarshaler(v.Interface().(json.Marshaler))
+	}
+	if v.Kind() != reflect.Ptr && v.CanAddr() && reflect.PtrTo(v.Type()).Implements(jsonMarshalerType) {
+		return prettyFromMarshaler(v.Addr().Interface().(json.Marshaler))
+	}
+	if v.Type().Implements(textMarshalerType) {
+		text, err := v.Interface().(encoding.TextMarshaler).MarshalText()
+		if err != nil {
+			return nil, err
+		}
+		return string(text), nil
+	}
+
+	switch v.Kind() {
+	case reflect.Interface:
+		return prettyValue(v.Elem(), visited)
+	case reflect.Ptr:
+		key := visitKey{ptr: v.Pointer()}
+		if visited[key] {
+			return nil, fmt.Errorf("encountered a cycle via %s", v.Type())
+		}
+		visited[key] = true
+		defer delete(visited, key)
+		return prettyValue(v.Elem(), visited)
+	case reflect.Struct:
+		return prettyStruct(v, visited)
+	case reflect.Map:
+		return prettyMap(v, visited)
+	case reflect.Slice:
+		if v.IsNil() {
+			return nil, nil
+		}
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			return base64.StdEncoding.EncodeToString(v.Bytes()), nil
+		}
+		// empty slices can not contain a cycle, their key could collide with a pointer
+		if v.Len() > 0 {
+			key := visitKey{ptr: v.Pointer(), len: v.Len()}
+			if visited[key] {
+				return nil, fmt.Errorf("encountered a cycle via %s", v.Type())
+			}
+			visited[key] = true
+			defer delete(visited, key)
+		}
+		fallthrough
+	case reflect.Array:
+		list := make([]interface{}, v.Len())
+		for i := range list {
+			item, err := prettyValue(v.Index(i), visited)
+			if err != nil {
+				return nil, err
+			}
+			list[i] = item
+		}
+		return list, nil
+	case reflect.Bool:
+		return v.Bool(), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int(), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return v.Uint(), nil
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), nil
+	case reflect.String:
+		return v.String(), nil
+	}
+
+	return nil, fmt.Errorf("unsupported type: %s", v.Type())
+}
+
+func prettyStruct(v reflect.Value, visited map[visitKey]bool) (interface{}, error) {
+	object := prettyObject{}
+	t := v.Type()
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := parseLogTag(field)
+		if tag.skip {
+			continue
+		}
+
+		fv := v.Field(i)
+
+		// embedded structs are flattened like encoding/json does
+		if field.Anonymous && tag.name == "" {
+			ft := field.Type
+			if ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				if fv.Kind() == reflect.Ptr {
+					if fv.IsNil() {
+						continue
+					}
+					fv = fv.Elem()
+				}
+				embedded, err := prettyStruct(fv, visited)
+				if err != nil {
+					return nil, err
+				}
+				object = append(object, embedded.(prettyObject)...)
+				continue
+			}
+		}
+
+		if field.PkgPath != "" {
+			continue
+		}
+		if tag.omitEmpty && isEmptyValue(fv) {
+			continue
+		}
+
+		name := fieldName(field, tag)
+		if tag.redact {
+			object = append(object, prettyEntry{Key: name, Value: maskTagged(fv)})
+			continue
+		}
+
+		value, err := prettyValue(fv, visited)
+		if err != nil {
+			return nil, err
+		}
+		object = append(object, prettyEntry{Key: name, Value: value})
+	}
+	return object, nil
+}
+
+func prettyMap(v reflect.Value, visited map[visitKey]bool) (interface{}, error) {
+	if v.IsNil() {
+		return nil, nil
+	}
+
+	key := visitKey{ptr: v.Pointer()}
+	if visited[key] {
+		return nil, fmt.Errorf("encountered a cycle via %s", v.Type())
+	}
+	visited[key] = true
+	defer delete(visited, key)
+
+	object := make(prettyObject, 0, v.Len())
+	iter := v.MapRange()
+	for iter.Next() {
+		key, err := mapKey(iter.Key())
+		if err != nil {
+			return nil, err
+		}
+		value, err := prettyValue(iter.Value(), visited)
+		if err != nil {
+			return nil, err
+		}
+		object = append(object, prettyEntry{Key: key, Value: value})
+	}
+
+	sort.Slice(object, func(i, j int) bool {
+		return object[i].Key < object[j].Key
+	})
+	return object, nil
+}
+
+// mapKey converts a map key to a string like encoding/json does
+func mapKey(key reflect.Value) (string, error) {
+	if key.Kind() == reflect.String {
+		return key.String(), nil
+	}
+	if key.Type().Implements(textMarshalerType) {
+		text, err := key.Interface().(encoding.TextMarshaler).MarshalText()
+		return string(text), err
+	}
+	switch key.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return fmt.Sprint(key.Interface()), nil
+	}
+	return "", fmt.Errorf("unsupported map key type: %s", key.Type())
+}
+
+// prettyFromMarshaler converts the output of a json.Marshaler to a pretty tree
+func prettyFromMarshaler(marshaler json.Marshaler) (interface{}, error) {
+	b, err := marshaler.MarshalJSON()
+	if err != nil {
+		return nil, err
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(b))
+	decoder.UseNumber()
+
+	var decoded interface{}
+	if err := decoder.Decode(&decoded); err != nil {
+		return nil, err
+	}
+	return prettyValue(reflect.ValueOf(decoded), map[visitKey]bool{})
+}
+
+// maskTagged masks the value of a field tagged with `log:"redact"`
+func maskTagged(v reflect.Value) string {
+	if redactor == nil || !v.CanInterface() {
+		return redacted
+	}
+	return redactor.mask(fmt.Sprint(v.Interface()))
+}
+
+// isEmptyValue reports whether v is empty as defined by the omitempty option of encoding/json
+func isEmptyValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Bool:
+		return !v.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int() == 0
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return v.Uint() == 0
+	case reflect.Float32, reflect.Float64:
+		return v.Float() == 0
+	case reflect.Interface, reflect.Ptr:
+		return v.IsNil()
+	}
+	return false
+}
+
+// marshalPretty renders the value as JSON honoring the log struct tags.
+// If indent is empty the JSON is rendered in a single line.
+func marshalPretty(value interface{}, indent string) (string, error) {
+	tree, err := toPretty(value)
+	if err != nil {
+		return "", err
+	}
+
+	var sb strings.Builder
+	if err := writePrettyJSON(&sb, tree, indent, 0); err != nil {
+		return "", err
+	}
+	return sb.String(), nil
+}
+
+func writePrettyJSON(sb *strings.Builder, value interface{}, indent string, depth int) error {
+	newline := func(depth int) {
+		if indent != "" {
+			sb.WriteString("\n")
+			sb.WriteString(strings.Repeat(indent, depth))
+		}
+	}
+
+	switch v := value.(type) {
+	case prettyObject:
+		if len(v) == 0 {
+			sb.WriteString("{}")
+			return nil
+		}
+		sb.WriteString("{")
+		for i, entry := range v {
+			if i > 0 {
+				sb.WriteString(",")
+			}
+			newline(depth + 1)
+			key, _ := json.Marshal(entry.Key)
+			sb.Write(key)
+			sb.WriteString(":")
+			if indent != "" {
+				sb.WriteString(" ")
+			}
+			if err := writePrettyJSON(sb, entry.Value, indent, depth+1); err != nil {
+				return err
+			}
+		}
+		newline(depth)
+		sb.WriteString("}")
+	case []interface{}:
+		if len(v) == 0 {
+			sb.WriteString("[]")
+			return nil
+		}
+		sb.WriteString("[")
+		for i, item := range v {
+			if i > 0 {
+				sb.WriteString(",")
+			}
+			newline(depth + 1)
+			if err := writePrettyJSON(sb, item, indent, depth+1); err != nil {
+				return err
+			}
+		}
+		newline(depth)
+		sb.WriteString("]")
+	case json.Number:
+		sb.WriteString(v.String())
+	default:
+		b, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		sb.Write(b)
+	}
+	return nil
+}
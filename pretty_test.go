@@ -0,0 +1,99 @@
+package log
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type credentials struct {
+	User     string `json:"user"`
+	Password string `json:"-" log:"redact"`
+	Token    string `log:"-"`
+	Comment  string `log:"omitempty"`
+	Internal string `json:"-"`
+	Role     string `json:"role" log:"name=permission"`
+	secret   string
+}
+
+type account struct {
+	credentials
+	ID      int
+	Created time.Time
+	Tags    map[string]int
+	Raw     []byte
+	Parent  *account
+}
+
+func TestMarshalPrettyTags(t *testing.T) {
+	acc := account{
+		credentials: credentials{
+			User:     "bob",
+			Password: "hunter2",
+			Token:    "abc",
+			Internal: "visible in logs",
+			Role:     "admin",
+			secret:   "never",
+		},
+		ID:      42,
+		Created: time.Date(2022, 2, 15, 22, 32, 38, 0, time.UTC),
+		Tags:    map[string]int{"b": 2, "a": 1},
+		Raw:     []byte("hi"),
+	}
+
+	expected := `{"user":"bob","Password":"[REDACTED]","Internal":"visible in logs","permission":"admin",` +
+		`"ID":42,"Created":"2022-02-15T22:32:38Z","Tags":{"a":1,"b":2},"Raw":"aGk=","Parent":null}`
+
+	output, err := marshalPretty(acc, "")
+	assert.NoError(t, err)
+	assert.Equal(t, expected, output)
+}
+
+func TestMarshalPrettyIndent(t *testing.T) {
+	output, err := marshalPretty([]interface{}{map[string]interface{}{"a": []int{1, 2}, "b": struct{}{}, "c": []string{}}}, "  ")
+	assert.NoError(t, err)
+	assert.Equal(t, "[\n  {\n    \"a\": [\n      1,\n      2\n    ],\n    \"b\": {},\n    \"c\": []\n  }\n]", output)
+}
+
+func TestMarshalPrettyCycle(t *testing.T) {
+	acc := &account{}
+	acc.Parent = acc
+
+	_, err := marshalPretty(acc, "")
+	assert.Error(t, err)
+}
+
+func TestMarshalPrettySliceCycle(t *testing.T) {
+	captureMessages(t)
+
+	s := []interface{}{nil, "x"}
+	s[0] = s
+
+	_, err := marshalPretty(s, "")
+	assert.Error(t, err)
+
+	// the subslices share the data pointer but are no cycle
+	shared := []interface{}{"a", "b"}
+	_, err = marshalPretty([]interface{}{shared, shared[:1]}, "")
+	assert.NoError(t, err)
+
+	output := SprettyPrint(INFO, s)
+	assert.Contains(t, output, "<cycle>")
+}
+
+func TestMarshalPrettyRedactMode(t *testing.T) {
+	SetRedactor(NewRedactor(MaskPartial))
+	defer SetRedactor(nil)
+
+	output, err := marshalPretty(credentials{Password: "correct horse battery"}, "")
+	assert.NoError(t, err)
+	assert.Contains(t, output, `"Password":"*****************tery"`)
+}
+
+func TestStringifyStructField(t *testing.T) {
+	msg := Message{}
+	msg.SetField("login", credentials{User: "bob", Password: "hunter2"})
+
+	assert.Equal(t, `text login={"user":"bob","Password":"[REDACTED]","Internal":"","permission":""}`, appendFields("text", msg.Fields))
+}
@@ -0,0 +1,402 @@
+package log
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"runtime"
+	"sync"
+	"time"
+)
+
+// DefaultFlightRecorderSize is the number of messages kept by the default FlightRecorder
+const DefaultFlightRecorderSize = 256
+
+const (
+	// maxRecordedArgs and maxRecordedFields bound the values recorded for log calls of levels which are not shown
+	maxRecordedArgs   = 8
+	maxRecordedFields = 8
+)
+
+// flightRecorder is the FlightRecorder set by SetFlightRecorder
+var flightRecorder = NewFlightRecorder(DefaultFlightRecorderSize)
+
+// FlightRecorder keeps the last messages in memory, so they can be inspected or dumped after a failure.
+//
+// If RecordDisabledLevels is set, log calls of levels which are not shown are recorded, too. They are recorded
+// in a cheap form: the level, the message or format string and up to 8 parameters are stored without resolving
+// the caller, formatting the message or evaluating lazy values. Only strings, numbers and bools are kept,
+// other parameters are replaced by their type, e.g. <map[string]int>, and lazy values by <lazy>.
+type FlightRecorder struct {
+	mu       sync.Mutex
+	entries  []recorderEntry
+	next     int
+	count    int
+	dumpFile string
+
+	// disabledLevels is read without the lock by every log call of a level which is not shown
+	disabledLevels bool
+}
+
+// callKind defines how the message of a recorded log call is built
+type callKind uint8
+
+const (
+	callPrint callKind = iota
+	callPrintln
+	callPrintf
+	callLog
+)
+
+// recorderEntry is either a built Message or a log call of a level which is not shown
+type recorderEntry struct {
+	message Message
+	call    bool
+
+	// the log call, args and fields reuse their backing arrays so recording does not allocate
+	time    time.Time
+	level   LogLevel
+	name    string
+	kind    callKind
+	text    string
+	args    []interface{}
+	fields  []Field
+	dropped int
+}
+
+// NewFlightRecorder returns a FlightRecorder keeping the last size messages
+func NewFlightRecorder(size int) *FlightRecorder {
+	if size < 0 {
+		size = 0
+	}
+	entries := make([]recorderEntry, size)
+	for i := range entries {
+		entries[i].args = make([]interface{}, 0, maxRecordedArgs)
+		entries[i].fields = make([]Field, 0, maxRecordedFields)
+	}
+	return &FlightRecorder{entries: entries}
+}
+
+// SetFlightRecorder sets the FlightRecorder which records every message passed to the handlers.
+// By default, a FlightRecorder keeping the last DefaultFlightRecorderSize messages is set.
+// Set to nil to disable recording.
+func SetFlightRecorder(r *FlightRecorder) {
+	flightRecorder = r
+}
+
+// GetFlightRecorder returns the FlightRecorder set by SetFlightRecorder or nil if recording is disabled
+func GetFlightRecorder() *FlightRecorder {
+	return flightRecorder
+}
+
+// Handler returns a Handler adding every message to the FlightRecorder.
+// Use it to record only the messages of specific levels instead of using SetFlightRecorder.
+func (r *FlightRecorder) Handler() Handler {
+	return r.record
+}
+
+// RecordDisabledLevels sets if log calls of levels which are not shown are recorded.
+// Recording them costs a lock and reading the clock for every log call of a disabled level,
+// so it is disabled by default. Set it before the FlightRecorder is passed to SetFlightRecorder.
+func (r *FlightRecorder) RecordDisabledLevels(enabled bool) {
+	r.disabledLevels = enabled
+}
+
+// SetDumpFile sets the file Recover dumps the messages to. By default, the messages are dumped to stderr.
+func (r *FlightRecorder) SetDumpFile(path string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.dumpFile = path
+}
+
+// Messages returns the recorded messages from the oldest to the newest
+func (r *FlightRecorder) Messages() []Message {
+	return r.Query(nil)
+}
+
+// Query returns the recorded messages for which filter returns true from the oldest to the newest.
+// If filter is nil all messages are returned.
+func (r *FlightRecorder) Query(filter func(message Message) bool) []Message {
+	r.mu.Lock()
+	entries := make([]recorderEntry, 0, r.count)
+	start := r.next - r.count
+	if start < 0 {
+		start += len(r.entries)
+	}
+	for i := 0; i < r.count; i++ {
+		entry := r.entries[(start+i)%len(r.entries)]
+		// copy the reused backing arrays, the messages are built without holding the lock
+		entry.args = append([]interface{}(nil), entry.args...)
+		entry.fields = append([]Field(nil), entry.fields...)
+		entries = append(entries, entry)
+	}
+	r.mu.Unlock()
+
+	messages := make([]Message, 0, len(entries))
+	for _, entry := range entries {
+		message := entry.build()
+		if filter == nil || filter(message) {
+			messages = append(messages, message)
+		}
+	}
+	return messages
+}
+
+// Dump writes all recorded messages as text without colors to w
+func (r *FlightRecorder) Dump(w io.Writer) error {
+	messages := r.Messages()
+
+	buf := &bytes.Buffer{}
+	fmt.Fprintf(buf, "=== flight recorder: %d messages ===\n", len(messages))
+	for _, message := range messages {
+		writeMessage(buf, message, false)
+		if !bytes.HasSuffix(buf.Bytes(), []byte("\n")) {
+			buf.WriteByte('\n')
+		}
+	}
+	buf.WriteString("=== end of flight recorder ===\n")
+
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// DumpFile writes all recorded messages to the file, an existing file is overwritten
+func (r *FlightRecorder) DumpFile(path string) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("could not create flight recorder dump: %w", err)
+	}
+
+	if err := r.Dump(file); err != nil {
+		_ = file.Close()
+		return fmt.Errorf("could not write flight recorder dump: %w", err)
+	}
+	return file.Close()
+}
+
+// record adds the message, it does nothing if the FlightRecorder is nil
+func (r *FlightRecorder) record(message Message) {
+	if r == nil {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	entry := r.slot()
+	if entry == nil {
+		return
+	}
+	entry.message = message
+	entry.call = false
+}
+
+// recordCall adds a log call of a level which is not shown without building the Message.
+// text is the message of Log or the format string of Printf, base are the fields of the Logger.
+// It does nothing if the FlightRecorder is nil or does not record disabled levels.
+func (r *FlightRecorder) recordCall(level LogLevel, name string, kind callKind, text string, args []interface{}, base []Field, fields []Field) {
+	if r == nil || !r.disabledLevels || level == NONE {
+		return
+	}
+	now := time.Now()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	entry := r.slot()
+	if entry == nil {
+		return
+	}
+	entry.message = Message{}
+	entry.call = true
+	entry.time, entry.level, entry.name, entry.kind, entry.text = now, level, name, kind, text
+
+	entry.dropped = 0
+	if len(args) > maxRecordedArgs {
+		entry.dropped += len(args) - maxRecordedArgs
+		args = args[:maxRecordedArgs]
+	}
+	entry.args = entry.args[:0]
+	for _, arg := range args {
+		entry.args = append(entry.args, snapshot(arg))
+	}
+
+	entry.fields = entry.fields[:0]
+	for _, fields := range [2][]Field{base, fields} {
+		if free := maxRecordedFields - len(entry.fields); len(fields) > free {
+			entry.dropped += len(fields) - free
+			fields = fields[:free]
+		}
+		for _, f := range fields {
+			f, _ = snapshotField(f)
+			entry.fields = append(entry.fields, f)
+		}
+	}
+}
+
+// snapshot returns the parameter if it can not change after the log call.
+// Lazy values are replaced by <lazy> and all other values by their type,
+// so the FlightRecorder does not keep references to values of the caller.
+func snapshot(param interface{}) interface{} {
+	if param == nil {
+		return nil
+	}
+	if isLazy(param) {
+		return "<lazy>"
+	}
+	if f, ok := param.(Field); ok {
+		if f, changed := snapshotField(f); changed {
+			return f
+		}
+		return param
+	}
+	if t := reflect.TypeOf(param); !isScalar(t) {
+		return t
+	}
+	return param
+}
+
+// snapshotField replaces values of the field which may change after the log call by their type
+func snapshotField(f Field) (Field, bool) {
+	switch f.kind {
+	case errorField, stringerField, anyField:
+		if f.value == nil {
+			return f, false
+		}
+		if t := reflect.TypeOf(f.value); !isScalar(t) {
+			return Field{Key: f.Key, value: t}, true
+		}
+	}
+	return f, false
+}
+
+// isScalar returns if values of the type are strings, numbers or bools
+func isScalar(t reflect.Type) bool {
+	kind := t.Kind()
+	return kind == reflect.String || kind >= reflect.Bool && kind <= reflect.Complex128
+}
+
+// placeholder returns the text shown for a value replaced by snapshot
+func placeholder(t reflect.Type) string {
+	return "<" + t.String() + ">"
+}
+
+// slot returns the entry to overwrite with the next message, the oldest entry if the FlightRecorder is full.
+// The caller must hold the lock.
+func (r *FlightRecorder) slot() *recorderEntry {
+	if len(r.entries) == 0 {
+		return nil
+	}
+
+	entry := &r.entries[r.next]
+	r.next = (r.next + 1) % len(r.entries)
+	if r.count < len(r.entries) {
+		r.count++
+	}
+	return entry
+}
+
+// build returns the recorded Message or builds it from the recorded log call
+func (e recorderEntry) build() Message {
+	if !e.call {
+		return e.message
+	}
+
+	args := make([]interface{}, len(e.args))
+	for i, arg := range e.args {
+		if t, ok := arg.(reflect.Type); ok {
+			arg = placeholder(t)
+		}
+		args[i] = arg
+	}
+	fields, args := splitFields(e.fields, args)
+	fields = append([]Field(nil), fields...)
+	for i, f := range fields {
+		if t, ok := f.value.(reflect.Type); ok && f.kind == anyField {
+			fields[i] = String(f.Key, placeholder(t))
+		}
+	}
+
+	var text string
+	switch e.kind {
+	case callPrint:
+		text = fmt.Sprint(args...)
+	case callPrintln:
+		text = fmt.Sprint(args...) + "\n"
+	case callPrintf:
+		text = fmt.Sprintf(e.text, args...)
+	case callLog:
+		text = e.text + "\n"
+	}
+
+	message := Message{
+		Time:    e.time,
+		Level:   e.level,
+		Name:    e.name,
+		Message: text,
+		Fields:  fields,
+		Errors:  collectErrors(args),
+	}
+	if e.dropped > 0 {
+		message.setField(Int("dropped", e.dropped))
+	}
+	if redactor != nil {
+		message = redactor.redactMessage(message)
+	}
+	return message
+}
+
+// dump writes the messages to the dump file or stderr if no dump file is set or it can not be written
+func (r *FlightRecorder) dump() {
+	r.mu.Lock()
+	path := r.dumpFile
+	r.mu.Unlock()
+
+	if path != "" && r.DumpFile(path) == nil {
+		return
+	}
+	_ = r.Dump(os.Stderr)
+}
+
+// Recover logs a panic with its value and stack trace at CRITICAL, dumps the FlightRecorder and panics again.
+// Recover must be deferred directly:
+//
+//	defer log.Recover()
+func Recover() {
+	value := recover()
+	if value == nil {
+		return
+	}
+
+	// skip runtime.Callers, captureStack and Recover, frames of the runtime are trimmed
+	stack := captureStack(3)
+
+	message := Message{
+		Time:    time.Now(),
+		Level:   CRITICAL,
+		Message: fmt.Sprintf("panic: %v\n", value),
+		Stack:   stack,
+	}
+	if len(stack) > 0 {
+		message.Caller, _ = newCaller(runtime.Frame{Function: stack[0].Function, File: stack[0].File, Line: stack[0].Line})
+	}
+	if err, ok := value.(error); ok {
+		message.Errors = []ErrorInfo{newErrorInfo(err)}
+	}
+	if redactor != nil {
+		message = redactor.redactMessage(message)
+	}
+
+	flightRecorder.record(message)
+	if showMe(CRITICAL) {
+		dispatch(getLevelConfig(CRITICAL).Handlers, message)
+	}
+	if flightRecorder != nil {
+		flightRecorder.dump()
+	}
+
+	panic(value)
+}
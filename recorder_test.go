@@ -0,0 +1,213 @@
+package log
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func captureFlightRecorder(t *testing.T, size int, disabledLevels bool) *FlightRecorder {
+	t.Helper()
+
+	prev := flightRecorder
+	t.Cleanup(func() {
+		SetFlightRecorder(prev)
+	})
+
+	recorder := NewFlightRecorder(size)
+	recorder.RecordDisabledLevels(disabledLevels)
+	SetFlightRecorder(recorder)
+	return recorder
+}
+
+func TestFlightRecorderRecordsAllLevels(t *testing.T) {
+	messages := captureMessages(t)
+	SetLogLevel(WARN)
+	recorder := captureFlightRecorder(t, 3, true)
+
+	Println(VERBOSE, "verbose")
+	Println(DEBUG, "debug")
+	Println(INFO, "info")
+	Println(ERROR, "error")
+
+	assert.Len(t, *messages, 1)
+
+	recorded := recorder.Messages()
+	assert.Len(t, recorded, 3)
+	assert.Equal(t, "debug\n", recorded[0].Message)
+	assert.Equal(t, "error\n", recorded[2].Message)
+	// the caller is only resolved for shown levels
+	assert.Equal(t, Caller{}, recorded[0].Caller)
+	assert.Equal(t, "recorder_test.go", recorded[2].Caller.Path)
+
+	infos := recorder.Query(func(message Message) bool {
+		return message.Level == INFO
+	})
+	assert.Len(t, infos, 1)
+}
+
+func TestFlightRecorderIsEnabledByDefault(t *testing.T) {
+	assert.NotNil(t, GetFlightRecorder())
+	assert.Len(t, GetFlightRecorder().entries, DefaultFlightRecorderSize)
+}
+
+func TestFlightRecorderDisabledLevelsAreCheap(t *testing.T) {
+	captureMessages(t)
+	SetLogLevel(INFO)
+	recorder := captureFlightRecorder(t, 10, true)
+
+	evaluated := false
+	lazy := Lazy(func() interface{} {
+		evaluated = true
+		return "expensive"
+	})
+	logger := With(String("request", "r1"))
+
+	assert.False(t, logger.Enabled(DEBUG))
+	logger.Println(DEBUG, "lazy", lazy)
+	logger.Printf(DEBUG, "user %d of %s", 7, "alice")
+	logger.Log(DEBUG, "typed", Int("attempt", 3))
+	logger.PrettyPrint(DEBUG, map[string]int{"a": 1})
+	logger.Println(DEBUG, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10)
+
+	assert.False(t, evaluated)
+
+	recorded := recorder.Messages()
+	assert.Len(t, recorded, 5)
+	assert.Equal(t, "lazy<lazy>\n", recorded[0].Message)
+	assert.Equal(t, "user 7 of alice", recorded[1].Message)
+	assert.Equal(t, "typed\n", recorded[2].Message)
+	assert.Equal(t, "<map[string]int>\n", recorded[3].Message)
+	assert.Equal(t, "1 2 3 4 5 6 7 8\n", recorded[4].Message)
+	for _, message := range recorded {
+		assert.Equal(t, DEBUG, message.Level)
+		request, _ := message.Field("request")
+		assert.Equal(t, "r1", request)
+	}
+	attempt, _ := recorded[2].Field("attempt")
+	assert.Equal(t, 3, attempt)
+	dropped, _ := recorded[4].Field("dropped")
+	assert.Equal(t, 2, dropped)
+}
+
+func TestFlightRecorderIgnoresDisabledLevelsByDefault(t *testing.T) {
+	captureMessages(t)
+	SetLogLevel(INFO)
+	recorder := captureFlightRecorder(t, 10, false)
+
+	Println(DEBUG, "disabled")
+	Println(INFO, "enabled")
+
+	recorded := recorder.Messages()
+	assert.Len(t, recorded, 1)
+	assert.Equal(t, "enabled\n", recorded[0].Message)
+}
+
+func TestFlightRecorderKeepsNoReferences(t *testing.T) {
+	captureMessages(t)
+	SetLogLevel(INFO)
+	recorder := captureFlightRecorder(t, 10, true)
+
+	values := map[string]int{"a": 1}
+	Println(DEBUG, "values", values, 42)
+	Log(DEBUG, "typed", Any("values", values), Err(errors.New("failed")), Int("n", 1))
+
+	done := make(chan bool)
+	go func() {
+		for i := 0; i < 1000; i++ {
+			values["b"] = i
+		}
+		close(done)
+	}()
+	recorded := recorder.Messages()
+	<-done
+
+	assert.Equal(t, "values<map[string]int>42\n", recorded[0].Message)
+	value, _ := recorded[1].Field("values")
+	assert.Equal(t, "<map[string]int>", value)
+	err, _ := recorded[1].Field("error")
+	assert.Equal(t, "<*errors.errorString>", err)
+	n, _ := recorded[1].Field("n")
+	assert.Equal(t, 1, n)
+}
+
+func TestFlightRecorderDoesNotAllocate(t *testing.T) {
+	captureMessages(t)
+	SetLogLevel(INFO)
+	captureFlightRecorder(t, 10, true)
+
+	allocs := testing.AllocsPerRun(100, func() {
+		Println(DEBUG, "disabled")
+		Printf(DEBUG, "disabled %s", "message")
+		Log(DEBUG, "disabled", String("key", "value"))
+	})
+	assert.Zero(t, allocs)
+}
+
+func TestFlightRecorderDump(t *testing.T) {
+	captureMessages(t)
+	recorder := captureFlightRecorder(t, 10, false)
+
+	Println(INFO, "first")
+	Printf(DEBUG, "second %d", 2)
+
+	out := &bytes.Buffer{}
+	assert.NoError(t, recorder.Dump(out))
+	assert.Contains(t, out.String(), "=== flight recorder: 2 messages ===\n")
+	assert.Contains(t, out.String(), "[INFO] first\n")
+	assert.Contains(t, out.String(), "second 2\n")
+	assert.NotContains(t, out.String(), "\x1b[")
+
+	path := filepath.Join(t.TempDir(), "dump.log")
+	assert.NoError(t, recorder.DumpFile(path))
+	content, err := ioutil.ReadFile(path)
+	assert.NoError(t, err)
+	assert.Equal(t, out.String(), string(content))
+}
+
+func TestFlightRecorderHandler(t *testing.T) {
+	messages := captureMessages(t)
+	recorder := NewFlightRecorder(10)
+	config.Error.AddHandler(recorder.Handler())
+
+	Println(INFO, "info")
+	Println(ERROR, "error")
+
+	assert.Len(t, *messages, 2)
+	assert.Len(t, recorder.Messages(), 1)
+}
+
+func panicking() {
+	defer Recover()
+	panic(errors.New("boom"))
+}
+
+func TestRecover(t *testing.T) {
+	messages := captureMessages(t)
+	recorder := captureFlightRecorder(t, 10, false)
+	path := filepath.Join(t.TempDir(), "crash.log")
+	recorder.SetDumpFile(path)
+
+	Println(DEBUG, "before panic")
+
+	assert.PanicsWithError(t, "boom", panicking)
+
+	assert.Len(t, *messages, 2)
+	critical := (*messages)[1]
+	assert.Equal(t, CRITICAL, critical.Level)
+	assert.Equal(t, "panic: boom\n", critical.Message)
+	assert.Equal(t, "recorder_test.go", critical.Caller.Path)
+	assert.Equal(t, "panicking", critical.Caller.FunctionName)
+	assert.True(t, strings.HasSuffix(critical.Stack[0].Function, ".panicking"))
+	assert.Len(t, critical.Errors, 1)
+
+	content, err := ioutil.ReadFile(path)
+	assert.NoError(t, err)
+	assert.Contains(t, string(content), "before panic")
+	assert.Contains(t, string(content), "panic: boom")
+}
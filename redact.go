@@ -0,0 +1,292 @@
+package log
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"regexp"
+	"strings"
+)
+
+// MaskMode defines how detected secrets are masked
+type MaskMode uint
+
+const (
+	// MaskFull replaces the secret with [REDACTED]
+	MaskFull MaskMode = iota
+	// MaskPartial replaces all but the last four characters of the secret with '*'
+	MaskPartial
+	// MaskHash replaces the secret with a short SHA-256 hash, so equal secrets can still be correlated
+	MaskHash
+)
+
+const redacted = "[REDACTED]"
+
+// Detector finds secrets in log messages.
+// If Pattern contains a capture group named "secret" only this group is masked.
+// Validate is optional and can be used to discard false positives.
+type Detector struct {
+	Name     string
+	Pattern  *regexp.Regexp
+	Validate func(match string) bool
+}
+
+// Built-in detectors
+var (
+	BearerTokenDetector = Detector{
+		Name:    "bearer",
+		Pattern: regexp.MustCompile(`(?i)\bbearer\s+(?P<secret>[A-Za-z0-9\-._~+/]+=*)`),
+	}
+	AWSKeyDetector = Detector{
+		Name:    "aws",
+		Pattern: regexp.MustCompile(`\b(?:AKIA|ASIA|AGPA|AIDA|AROA|ANPA|ANVA|AIPA)[A-Z0-9]{16}\b|(?i)aws_?secret_?access_?key["']?\s*[:=]\s*["']?(?P<secret>[A-Za-z0-9/+=]{40})`),
+	}
+	JWTDetector = Detector{
+		Name:    "jwt",
+		Pattern: regexp.MustCompile(`\beyJ[A-Za-z0-9_-]+\.eyJ[A-Za-z0-9_-]+\.[A-Za-z0-9_-]*`),
+	}
+	CreditCardDetector = Detector{
+		Name:     "creditcard",
+		Pattern:  regexp.MustCompile(`\b(?:\d[ -]?){12,18}\d\b`),
+		Validate: luhn,
+	}
+	EmailDetector = Detector{
+		Name:    "email",
+		Pattern: regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`),
+	}
+	IPDetector = Detector{
+		Name:    "ip",
+		Pattern: regexp.MustCompile(`\b(?:\d{1,3}\.){3}\d{1,3}\b|(?i)(?:[0-9a-f]{0,4}:){2,7}[0-9a-f]{1,4}\b`),
+		Validate: func(match string) bool {
+			return net.ParseIP(match) != nil
+		},
+	}
+)
+
+// DefaultDetectors returns all built-in detectors
+func DefaultDetectors() []Detector {
+	return []Detector{
+		BearerTokenDetector,
+		JWTDetector,
+		AWSKeyDetector,
+		CreditCardDetector,
+		EmailDetector,
+		IPDetector,
+	}
+}
+
+// Redactor masks secrets and personal data in messages before they are passed to any handler
+type Redactor struct {
+	mode      MaskMode
+	detectors []Detector
+	denyKeys  map[string]bool
+	keyRegex  *regexp.Regexp
+}
+
+// NewRedactor returns a Redactor using the given MaskMode and detectors
+func NewRedactor(mode MaskMode, detectors ...Detector) *Redactor {
+	return &Redactor{
+		mode:      mode,
+		detectors: detectors,
+		denyKeys:  map[string]bool{},
+	}
+}
+
+// DefaultRedactor returns a Redactor with all built-in detectors and a deny list of common secret key names
+func DefaultRedactor() *Redactor {
+	r := NewRedactor(MaskFull, DefaultDetectors()...)
+	r.DenyKeys("password", "passwd", "secret", "token", "api_key", "apikey", "authorization")
+	return r
+}
+
+// SetRedactor sets the Redactor which is applied to every message.
+// Set to nil to disable redaction.
+func SetRedactor(r *Redactor) {
+	redactor = r
+}
+
+// AddDetector adds a custom Detector
+func (r *Redactor) AddDetector(detector Detector) {
+	r.detectors = append(r.detectors, detector)
+}
+
+// AddRule adds a custom Detector based on the given regular expression
+func (r *Redactor) AddRule(name string, expr string) error {
+	pattern, err := regexp.Compile(expr)
+	if err != nil {
+		return fmt.Errorf("invalid redaction rule '%s': %w", name, err)
+	}
+	r.AddDetector(Detector{Name: name, Pattern: pattern})
+	return nil
+}
+
+// DenyKeys adds key names whose values are always masked.
+// Keys are matched case-insensitive in fields as well as in key=value and "key": value pairs of the message text.
+func (r *Redactor) DenyKeys(keys ...string) {
+	for _, key := range keys {
+		r.denyKeys[strings.ToLower(key)] = true
+	}
+
+	quoted := make([]string, 0, len(r.denyKeys))
+	for key := range r.denyKeys {
+		quoted = append(quoted, regexp.QuoteMeta(key))
+	}
+	alternatives := strings.Join(quoted, "|")
+	r.keyRegex = regexp.MustCompile(`(?i)("(?:` + alternatives + `)"\s*:\s*|\b(?:` + alternatives + `)\s*[=:]\s*(?:\([^()\s]+\)\s*)?)("(?:[^"\\]|\\.)*"|[^\s,;}\]]+)`)
+}
+
+// Redact masks all secrets found in text
+func (r *Redactor) Redact(text string) string {
+	if r.keyRegex != nil {
+		text = r.keyRegex.ReplaceAllStringFunc(text, func(match string) string {
+			sub := r.keyRegex.FindStringSubmatch(match)
+			value := sub[2]
+			if len(value) >= 2 && strings.HasPrefix(value, `"`) && strings.HasSuffix(value, `"`) {
+				return sub[1] + `"` + r.mask(value[1:len(value)-1]) + `"`
+			}
+			return sub[1] + r.mask(value)
+		})
+	}
+
+	for _, detector := range r.detectors {
+		text = r.apply(detector, text)
+	}
+	return text
+}
+
+// RedactField masks the value of a field if its key is on the deny list or the value contains secrets
+func (r *Redactor) RedactField(key string, value interface{}) interface{} {
+	if r.denyKeys[strings.ToLower(key)] {
+		return r.mask(fmt.Sprint(value))
+	}
+	if str, ok := value.(string); ok {
+		return r.Redact(str)
+	}
+	return value
+}
+
+// redactMessage applies the redactor to the text and fields of the message
+func (r *Redactor) redactMessage(message Message) Message {
+	message.Message = r.Redact(message.Message)
+
+	if len(message.Fields) > 0 {
+		fields := make([]Field, len(message.Fields))
+		for i, f := range message.Fields {
+			fields[i] = r.redactField(f)
+		}
+		message.Fields = fields
+	}
+
+	if len(message.Errors) > 0 {
+		message.Errors = r.redactErrors(message.Errors)
+	}
+	return message
+}
+
+// redactField applies the redactor to the value of the field.
+// String fields are redacted without converting the value, error and Stringer fields
+// are only replaced by a string field if the redactor masked a part of their text.
+func (r *Redactor) redactField(f Field) Field {
+	switch {
+	case r.denyKeys[strings.ToLower(f.Key)]:
+		return String(f.Key, r.mask(fmt.Sprint(f.Value())))
+	case f.kind == stringField:
+		if redacted := r.Redact(f.str); redacted != f.str {
+			return String(f.Key, redacted)
+		}
+	case f.kind == anyField:
+		return Any(f.Key, r.RedactField(f.Key, f.value))
+	case f.kind == errorField || f.kind == stringerField:
+		if f.value != nil {
+			text := fmt.Sprint(f.value)
+			if redacted := r.Redact(text); redacted != text {
+				return String(f.Key, redacted)
+			}
+		}
+	}
+	return f
+}
+
+// redactErrors applies the redactor to the messages of the errors and their causes
+func (r *Redactor) redactErrors(errs []ErrorInfo) []ErrorInfo {
+	redactedErrs := make([]ErrorInfo, len(errs))
+	for i, info := range errs {
+		info.Message = r.Redact(info.Message)
+		info.Causes = r.redactErrors(info.Causes)
+		redactedErrs[i] = info
+	}
+	return redactedErrs
+}
+
+// apply masks all matches of the detector in text
+func (r *Redactor) apply(detector Detector, text string) string {
+	secret := detector.Pattern.SubexpIndex("secret")
+	matches := detector.Pattern.FindAllStringSubmatchIndex(text, -1)
+	if matches == nil {
+		return text
+	}
+
+	var sb strings.Builder
+	last := 0
+	for _, m := range matches {
+		start, end := m[0], m[1]
+		if secret > 0 && m[2*secret] >= 0 {
+			start, end = m[2*secret], m[2*secret+1]
+		}
+
+		match := text[start:end]
+		if detector.Validate != nil && !detector.Validate(match) {
+			continue
+		}
+
+		sb.WriteString(text[last:start])
+		sb.WriteString(r.mask(match))
+		last = end
+	}
+	sb.WriteString(text[last:])
+
+	return sb.String()
+}
+
+// mask masks the secret according to the MaskMode of the Redactor
+func (r *Redactor) mask(secret string) string {
+	switch r.mode {
+	case MaskPartial:
+		if len(secret) < 12 {
+			return strings.Repeat("*", len(secret))
+		}
+		return strings.Repeat("*", len(secret)-4) + secret[len(secret)-4:]
+	case MaskHash:
+		sum := sha256.Sum256([]byte(secret))
+		return "[sha256:" + hex.EncodeToString(sum[:])[:12] + "]"
+	default:
+		return redacted
+	}
+}
+
+// luhn validates a credit card number with the Luhn algorithm
+func luhn(number string) bool {
+	sum := 0
+	digits := 0
+	double := false
+
+	for i := len(number) - 1; i >= 0; i-- {
+		c := number[i]
+		if c == ' ' || c == '-' {
+			continue
+		}
+		d := int(c - '0')
+		if double {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		digits++
+		double = !double
+	}
+
+	return digits >= 13 && sum%10 == 0
+}
@@ -0,0 +1,50 @@
+package log
+
+// ring is a fixed size buffer of messages which overwrites the oldest message when it is full
+type ring struct {
+	buffer []Message
+	next   int
+	count  int
+}
+
+func newRing(size int) ring {
+	if size < 0 {
+		size = 0
+	}
+	return ring{buffer: make([]Message, size)}
+}
+
+// add adds the message and overwrites the oldest message if the ring is full
+func (r *ring) add(message Message) {
+	if len(r.buffer) == 0 {
+		return
+	}
+
+	r.buffer[r.next] = message
+	r.next = (r.next + 1) % len(r.buffer)
+	if r.count < len(r.buffer) {
+		r.count++
+	}
+}
+
+// messages returns a copy of all messages from the oldest to the newest
+func (r *ring) messages() []Message {
+	messages := make([]Message, 0, r.count)
+	start := r.next - r.count
+	if start < 0 {
+		start += len(r.buffer)
+	}
+	for i := 0; i < r.count; i++ {
+		messages = append(messages, r.buffer[(start+i)%len(r.buffer)])
+	}
+	return messages
+}
+
+// reset removes all messages
+func (r *ring) reset() {
+	for i := range r.buffer {
+		r.buffer[i] = Message{}
+	}
+	r.next = 0
+	r.count = 0
+}
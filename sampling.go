@@ -0,0 +1,322 @@
+package log
+
+import (
+	"sync"
+	"time"
+)
+
+// SampleKey defines how messages are grouped by a Sampler
+type SampleKey uint
+
+const (
+	// SampleByTemplate groups messages by their format string, or the first string parameter for unformatted messages
+	SampleByTemplate SampleKey = iota
+	// SampleByCaller groups messages by the code location calling the log function
+	SampleByCaller
+)
+
+// summaryInterval is the minimum time between two summaries of suppressed messages of a rule
+var summaryInterval = 10 * time.Second
+
+// SetSummaryInterval sets the minimum time between two summary messages stating how many messages
+// a Sampler or RateLimiter suppressed. The summary is emitted with the next message which passes the rule,
+// or after the interval if no further message is logged.
+//
+// Default is 10 seconds
+func SetSummaryInterval(interval time.Duration) {
+	summaryInterval = interval
+}
+
+// sampleGroup is the key of a group of messages counted by a Sampler
+type sampleGroup struct {
+	template string
+	pc       uintptr
+}
+
+type sampleCounter struct {
+	start time.Time
+	count int
+}
+
+// suppression counts the messages suppressed by a rule since the last summary
+type suppression struct {
+	suppressed int64
+	reported   time.Time
+
+	// timer emits the summary after the summary interval even if no further message is logged.
+	// level and name are the LogLevel and Logger name of the last suppressed message.
+	timer *time.Timer
+	level LogLevel
+	name  string
+}
+
+// summary returns the number of suppressed messages and resets the counter if the summary interval has passed
+func (s *suppression) summary(now time.Time) (int64, bool) {
+	if s.suppressed == 0 || now.Sub(s.reported) < summaryInterval {
+		return 0, false
+	}
+	if s.timer != nil {
+		s.timer.Stop()
+		s.timer = nil
+	}
+	count := s.suppressed
+	s.suppressed = 0
+	s.reported = now
+	return count, true
+}
+
+// take returns the number of suppressed messages and where they were logged and resets the counter
+func (s *suppression) take(now time.Time) (int64, LogLevel, string) {
+	s.timer = nil
+	count := s.suppressed
+	if count > 0 {
+		s.suppressed = 0
+		s.reported = now
+	}
+	return count, s.level, s.name
+}
+
+// Sampler passes the first messages of every group per interval and thereafter only every nth message.
+// Set the Sampler in the LevelConfig of the levels which should be sampled.
+type Sampler struct {
+	interval   time.Duration
+	first      int
+	thereafter int
+	key        SampleKey
+
+	mu       sync.Mutex
+	counters map[sampleGroup]*sampleCounter
+	suppression
+	now func() time.Time
+}
+
+// NewSampler returns a Sampler which passes the first messages of every group per interval
+// and thereafter every nth message. If thereafter is 0 all further messages of the interval are dropped.
+func NewSampler(interval time.Duration, first int, thereafter int, key SampleKey) *Sampler {
+	return &Sampler{
+		interval:    interval,
+		first:       first,
+		thereafter:  thereafter,
+		key:         key,
+		counters:    map[sampleGroup]*sampleCounter{},
+		suppression: suppression{reported: time.Now()},
+		now:         time.Now,
+	}
+}
+
+// allow returns if the message of the group should be passed to the handlers
+func (s *Sampler) allow(group sampleGroup) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	counter, ok := s.counters[group]
+	if !ok || now.Sub(counter.start) >= s.interval {
+		if !ok && len(s.counters) >= maxSampleGroups {
+			s.expire(now)
+		}
+		counter = &sampleCounter{start: now}
+		s.counters[group] = counter
+	}
+	counter.count++
+
+	if counter.count <= s.first || (s.thereafter > 0 && (counter.count-s.first)%s.thereafter == 0) {
+		return true
+	}
+	s.suppressed++
+	return false
+}
+
+// maxSampleGroups limits the number of groups tracked by a Sampler
+const maxSampleGroups = 4096
+
+// expire removes all groups whose interval has passed
+func (s *Sampler) expire(now time.Time) {
+	for group, counter := range s.counters {
+		if now.Sub(counter.start) >= s.interval {
+			delete(s.counters, group)
+		}
+	}
+}
+
+func (s *Sampler) summary() (int64, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.suppression.summary(s.now())
+}
+
+// scheduleSummary starts the timer emitting the summary of the suppressed messages after the summary interval
+func (s *Sampler) scheduleSummary(level LogLevel, name string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.level, s.name = level, name
+	if s.timer == nil {
+		s.timer = time.AfterFunc(summaryInterval, s.flushSummary)
+	}
+}
+
+func (s *Sampler) flushSummary() {
+	s.mu.Lock()
+	count, level, name := s.take(s.now())
+	s.mu.Unlock()
+
+	emitSummary(level, name, "sampling", count)
+}
+
+// RateLimiter limits the number of messages per second with a token bucket.
+// Set the RateLimiter in the LevelConfig of the levels which should be limited.
+type RateLimiter struct {
+	rate  float64
+	burst float64
+
+	mu     sync.Mutex
+	tokens float64
+	last   time.Time
+	suppression
+	now func() time.Time
+}
+
+// NewRateLimiter returns a RateLimiter which passes rate messages per second on average and bursts of up to burst messages
+func NewRateLimiter(rate float64, burst int) *RateLimiter {
+	now := time.Now()
+	return &RateLimiter{
+		rate:        rate,
+		burst:       float64(burst),
+		tokens:      float64(burst),
+		last:        now,
+		suppression: suppression{reported: now},
+		now:         time.Now,
+	}
+}
+
+// allow returns if a message should be passed to the handlers and takes a token if so
+func (r *RateLimiter) allow() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := r.now()
+	r.tokens += now.Sub(r.last).Seconds() * r.rate
+	if r.tokens > r.burst {
+		r.tokens = r.burst
+	}
+	r.last = now
+
+	if r.tokens >= 1 {
+		r.tokens--
+		return true
+	}
+	r.suppressed++
+	return false
+}
+
+func (r *RateLimiter) summary() (int64, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.suppression.summary(r.now())
+}
+
+// scheduleSummary starts the timer emitting the summary of the suppressed messages after the summary interval
+func (r *RateLimiter) scheduleSummary(level LogLevel, name string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.level, r.name = level, name
+	if r.timer == nil {
+		r.timer = time.AfterFunc(summaryInterval, r.flushSummary)
+	}
+}
+
+func (r *RateLimiter) flushSummary() {
+	r.mu.Lock()
+	count, level, name := r.take(r.now())
+	r.mu.Unlock()
+
+	emitSummary(level, name, "ratelimit", count)
+}
+
+// sample returns if the message passes the Sampler and the RateLimiter of the LevelConfig.
+// skip is the number of frames above sample to the caller of the log function.
+// For suppressed messages the summary is scheduled, so it is emitted even if no further message is logged.
+func sample(cfg LevelConfig, level LogLevel, name string, skip int, params []interface{}) bool {
+	if cfg.Sampler != nil {
+		group := sampleGroup{}
+		if cfg.Sampler.key == SampleByCaller {
+			group.pc = callerPC(skip + 1)
+		} else {
+			group.template = template(params)
+		}
+		if !cfg.Sampler.allow(group) {
+			cfg.Sampler.scheduleSummary(level, name)
+			return false
+		}
+	}
+
+	if cfg.RateLimiter != nil && !cfg.RateLimiter.allow() {
+		cfg.RateLimiter.scheduleSummary(level, name)
+		return false
+	}
+	return true
+}
+
+// callerPC returns the program counter skip frames above callerPC, skipping functions marked with Helper
+// like the caller resolved by buildMessage
+func callerPC(skip int) uintptr {
+	pc, _ := resolveFrame(skip + 2)
+	return pc
+}
+
+// template returns the format string of a formatted message or the first string parameter
+func template(params []interface{}) string {
+	for _, param := range params {
+		switch p := param.(type) {
+		case formatted:
+			return p.format
+		case string:
+			return p
+		}
+	}
+	return ""
+}
+
+// suppressedSummaries returns the summary messages of the rules of the LevelConfig
+// whose summary interval has passed and which suppressed messages
+func suppressedSummaries(cfg LevelConfig) [][]interface{} {
+	var summaries [][]interface{}
+	if cfg.Sampler != nil {
+		if count, ok := cfg.Sampler.summary(); ok {
+			summaries = append(summaries, summaryParams("sampling", count))
+		}
+	}
+	if cfg.RateLimiter != nil {
+		if count, ok := cfg.RateLimiter.summary(); ok {
+			summaries = append(summaries, summaryParams("ratelimit", count))
+		}
+	}
+	return summaries
+}
+
+// summaryParams returns the parameters of the summary message of a rule
+func summaryParams(rule string, count int64) []interface{} {
+	text := "messages suppressed by sampling"
+	if rule == "ratelimit" {
+		text = "messages suppressed by rate limit"
+	}
+	return []interface{}{text, String("rule", rule), Int64("suppressed", count), "\n"}
+}
+
+// emitSummary passes a summary emitted by the timer of a rule to the handlers of the level.
+// The summary has no caller, because it is not logged by a log call.
+func emitSummary(level LogLevel, name string, rule string, count int64) {
+	if count == 0 {
+		return
+	}
+
+	l := &Logger{name: name, hideCaller: true}
+	if !l.shows(level) {
+		return
+	}
+	message := l.buildMessage(level, summaryParams(rule, count)...)
+	flightRecorder.record(message)
+	dispatch(l.levelConfig(level).Handlers, message)
+}
@@ -0,0 +1,144 @@
+package log
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeClock returns a function returning the current fake time and a function advancing it
+func fakeClock() (func() time.Time, func(time.Duration)) {
+	now := time.Date(2022, 2, 15, 22, 46, 51, 0, time.UTC)
+	return func() time.Time {
+			return now
+		}, func(d time.Duration) {
+			now = now.Add(d)
+		}
+}
+
+func TestSamplerByTemplate(t *testing.T) {
+	messages := captureMessages(t)
+	clock, advance := fakeClock()
+
+	sampler := NewSampler(time.Second, 2, 3, SampleByTemplate)
+	sampler.now = clock
+	config.Warn.Sampler = sampler
+
+	for i := 0; i < 10; i++ {
+		Printf(WARN, "retry %d", i)
+	}
+	Println(WARN, "other")
+
+	// the first 2, then every 3rd: 0, 1, 4, 7
+	assert.Len(t, *messages, 5)
+	assert.Equal(t, "retry 4", (*messages)[2].Message)
+	assert.Equal(t, "other\n", (*messages)[4].Message)
+
+	advance(time.Second)
+	Printf(WARN, "retry %d", 10)
+	assert.Len(t, *messages, 6)
+}
+
+func TestSamplerByCaller(t *testing.T) {
+	messages := captureMessages(t)
+
+	config.Info.Sampler = NewSampler(time.Hour, 1, 0, SampleByCaller)
+
+	for i := 0; i < 3; i++ {
+		Println(INFO, "first site", i)
+		Println(INFO, "second site", i)
+		New().Println(INFO, "third site", i)
+	}
+
+	assert.Len(t, *messages, 3)
+}
+
+func TestRateLimiter(t *testing.T) {
+	messages := captureMessages(t)
+	clock, advance := fakeClock()
+
+	limiter := NewRateLimiter(2, 3)
+	limiter.now = clock
+	limiter.last = clock()
+	config.Error.RateLimiter = limiter
+
+	for i := 0; i < 10; i++ {
+		Println(ERROR, "burst")
+	}
+	assert.Len(t, *messages, 3)
+
+	advance(time.Second)
+	for i := 0; i < 10; i++ {
+		Println(ERROR, "refilled")
+	}
+	assert.Len(t, *messages, 5)
+}
+
+func TestSuppressedSummary(t *testing.T) {
+	messages := captureMessages(t)
+	clock, advance := fakeClock()
+
+	limiter := NewRateLimiter(1, 1)
+	limiter.now = clock
+	limiter.last = clock()
+	limiter.reported = clock()
+	config.Error.RateLimiter = limiter
+
+	for i := 0; i < 5; i++ {
+		Println(ERROR, "flood")
+	}
+	assert.Len(t, *messages, 1)
+
+	advance(summaryInterval)
+	Println(ERROR, "flood")
+
+	assert.Len(t, *messages, 3)
+	summary := (*messages)[1]
+	assert.Equal(t, "messages suppressed by rate limit\n", summary.Message)
+	suppressed, _ := summary.Field("suppressed")
+	assert.Equal(t, int64(4), suppressed)
+	rule, _ := summary.Field("rule")
+	assert.Equal(t, "ratelimit", rule)
+
+	advance(summaryInterval)
+	Println(ERROR, "flood")
+	assert.Len(t, *messages, 4)
+}
+
+func TestSuppressedSummaryWithoutFurtherMessages(t *testing.T) {
+	captureMessages(t)
+	prevInterval := summaryInterval
+	SetSummaryInterval(20 * time.Millisecond)
+	t.Cleanup(func() {
+		SetSummaryInterval(prevInterval)
+	})
+
+	var mu sync.Mutex
+	var messages []Message
+	config.Error.SetHandlers([]Handler{func(message Message) {
+		mu.Lock()
+		defer mu.Unlock()
+		messages = append(messages, message)
+	}})
+	config.Error.RateLimiter = NewRateLimiter(0.001, 1)
+
+	for i := 0; i < 5; i++ {
+		Println(ERROR, "flood")
+	}
+
+	assert.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(messages) == 2
+	}, time.Second, 5*time.Millisecond)
+
+	mu.Lock()
+	defer mu.Unlock()
+	summary := messages[1]
+	assert.Equal(t, "messages suppressed by rate limit\n", summary.Message)
+	suppressed, _ := summary.Field("suppressed")
+	assert.Equal(t, int64(4), suppressed)
+	assert.Equal(t, Caller{}, summary.Caller)
+}
@@ -0,0 +1,68 @@
+package log
+
+import (
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// maxStackDepth is the maximum number of frames captured for a stack trace
+const maxStackDepth = 64
+
+// captureStack returns the stack trace of the calling goroutine.
+// skip is the number of frames to skip as defined by runtime.Callers.
+// Frames of the Go runtime are trimmed.
+func captureStack(skip int) []Frame {
+	pcs := make([]uintptr, maxStackDepth)
+	n := runtime.Callers(skip, pcs)
+	return framesOf(pcs[:n])
+}
+
+// framesOf resolves the program counters to frames, frames of the Go runtime are trimmed
+func framesOf(pcs []uintptr) []Frame {
+	if len(pcs) == 0 {
+		return nil
+	}
+
+	stack := make([]Frame, 0, len(pcs))
+	frames := runtime.CallersFrames(pcs)
+	for {
+		frame, more := frames.Next()
+		if !strings.HasPrefix(frame.Function, "runtime.") && frame.Function != "" {
+			stack = append(stack, Frame{
+				Function: frame.Function,
+				File:     frame.File,
+				Line:     frame.Line,
+			})
+		}
+		if !more {
+			break
+		}
+	}
+	return stack
+}
+
+// appendStack appends the stack trace to the message text in the format used by Go panics.
+// A trailing newline of the message is preserved.
+func appendStack(text string, stack []Frame) string {
+	if len(stack) == 0 {
+		return text
+	}
+
+	trimmed := strings.TrimSuffix(text, "\n")
+	newline := text[len(trimmed):]
+
+	var sb strings.Builder
+	sb.WriteString(trimmed)
+	for _, frame := range stack {
+		sb.WriteString("\n\t")
+		sb.WriteString(frame.Function)
+		sb.WriteString("\n\t\t")
+		sb.WriteString(frame.File)
+		sb.WriteString(":")
+		sb.WriteString(strconv.Itoa(frame.Line))
+	}
+	sb.WriteString(newline)
+
+	return sb.String()
+}
@@ -0,0 +1,37 @@
+package log
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCaptureStack(t *testing.T) {
+	messages := captureMessages(t)
+	config.Error.CaptureStack = true
+
+	Println(ERROR, "with stack")
+	Println(WARN, "without stack")
+
+	assert.Len(t, *messages, 2)
+	stack := (*messages)[0].Stack
+	assert.NotEmpty(t, stack)
+	assert.Equal(t, "github.com/chris-dot-exe/AwesomeLog.TestCaptureStack", stack[0].Function)
+	assert.True(t, strings.HasSuffix(stack[0].File, "stack_test.go"))
+	for _, frame := range stack {
+		assert.False(t, strings.HasPrefix(frame.Function, "runtime."))
+	}
+	assert.Empty(t, (*messages)[1].Stack)
+}
+
+func TestAppendStack(t *testing.T) {
+	stack := []Frame{
+		{Function: "main.handler", File: "/app/main.go", Line: 12},
+		{Function: "main.main", File: "/app/main.go", Line: 5},
+	}
+
+	expected := "failed\n\tmain.handler\n\t\t/app/main.go:12\n\tmain.main\n\t\t/app/main.go:5\n"
+	assert.Equal(t, expected, appendStack("failed\n", stack))
+	assert.Equal(t, "failed", appendStack("failed", nil))
+}
@@ -0,0 +1,308 @@
+package log
+
+import (
+	"encoding/json"
+	"os"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/term"
+)
+
+// TableConfig represents the configuration of PrettyPrintTable and SprettyPrintTable.
+// A TableConfig can also be passed as parameter to override the global config for a single call.
+type TableConfig struct {
+	// Columns selects and orders the shown columns. All columns are shown if empty.
+	Columns []string
+	// MaxColumnWidth truncates cells longer than the given width. 0 means unlimited.
+	MaxColumnWidth int
+	// MaxWidth limits the width of the table. If 0 the width of the terminal is used.
+	MaxWidth int
+	// Unicode uses box-drawing characters instead of ASCII for the borders
+	Unicode bool
+}
+
+var tableConfig = TableConfig{}
+
+type tableBorder struct {
+	horizontal, vertical               string
+	topLeft, topMid, topRight          string
+	midLeft, midMid, midRight          string
+	bottomLeft, bottomMid, bottomRight string
+	ellipsis                           string
+}
+
+var asciiBorder = tableBorder{
+	horizontal: "-", vertical: "|",
+	topLeft: "+", topMid: "+", topRight: "+",
+	midLeft: "+", midMid: "+", midRight: "+",
+	bottomLeft: "+", bottomMid: "+", bottomRight: "+",
+	ellipsis: "...",
+}
+
+var unicodeBorder = tableBorder{
+	horizontal: "─", vertical: "│",
+	topLeft: "┌", topMid: "┬", topRight: "┐",
+	midLeft: "├", midMid: "┼", midRight: "┤",
+	bottomLeft: "└", bottomMid: "┴", bottomRight: "┘",
+	ellipsis: "…",
+}
+
+// SetTableConfig sets the global config for PrettyPrintTable and SprettyPrintTable
+func SetTableConfig(cfg TableConfig) {
+	tableConfig = cfg
+}
+
+// PrettyPrintTable logs a message at the defined LogLevel with slices of structs or maps rendered as table.
+// Every element of the slice is a row, the fields or keys are the columns.
+func PrettyPrintTable(params ...interface{}) {
+	std.PrettyPrintTable(params...)
+}
+
+// SprettyPrintTable returns the log message of PrettyPrintTable as string
+func SprettyPrintTable(params ...interface{}) string {
+	return std.SprettyPrintTable(params...)
+}
+
+// PrettyPrintTable logs a message at the defined LogLevel with slices of structs or maps rendered as table
+func (l *Logger) PrettyPrintTable(params ...interface{}) {
+	level, _, params := getLogLevel(false, params...)
+	if l.skip(level, callPrintln, "", params, nil) {
+		return
+	}
+	params = evaluateLazy(params)
+	cfg, params := getTableConfig(params)
+	l.println(level, renderTables(cfg, params))
+}
+
+// SprettyPrintTable returns the log message of PrettyPrintTable as string
+func (l *Logger) SprettyPrintTable(params ...interface{}) string {
+	level, _, params := getLogLevel(false, params...)
+	if !l.shows(level) {
+		return ""
+	}
+	params = evaluateLazy(params)
+	cfg, params := getTableConfig(params)
+	return l.sprintln(level, renderTables(cfg, params))
+}
+
+// getTableConfig returns the TableConfig passed as parameter or the global config
+func getTableConfig(params []interface{}) (TableConfig, []interface{}) {
+	cfg := tableConfig
+	values := make([]interface{}, 0, len(params))
+	for _, param := range params {
+		if c, ok := param.(TableConfig); ok {
+			cfg = c
+			continue
+		}
+		values = append(values, param)
+	}
+	return cfg, values
+}
+
+// renderTables renders every value as table starting on a new line
+func renderTables(cfg TableConfig, values []interface{}) string {
+	if cfg.MaxWidth == 0 && isTerminal() {
+		if width, _, err := term.GetSize(int(os.Stdout.Fd())); err == nil {
+			cfg.MaxWidth = width
+		}
+	}
+
+	var sb strings.Builder
+	for _, value := range values {
+		tree, err := toPretty(value)
+		if err != nil {
+			return sdump(dumpConfig, values...)
+		}
+		sb.WriteString("\n")
+		sb.WriteString(renderTable(cfg, tree))
+	}
+	return strings.TrimSuffix(sb.String(), "\n")
+}
+
+type tableCell struct {
+	text    string
+	numeric bool
+}
+
+// renderTable renders a single value as table. Values which are not a list are rendered as a single row.
+func renderTable(cfg TableConfig, tree interface{}) string {
+	rows, ok := tree.([]interface{})
+	if !ok {
+		rows = []interface{}{tree}
+	}
+
+	columns := cfg.Columns
+	if len(columns) == 0 {
+		columns = tableColumns(rows)
+	}
+
+	cells := make([][]tableCell, len(rows))
+	for i, row := range rows {
+		cells[i] = make([]tableCell, len(columns))
+		object, isObject := row.(prettyObject)
+		for j, column := range columns {
+			var value interface{}
+			found := false
+			if isObject {
+				for _, entry := range object {
+					if entry.Key == column {
+						value, found = entry.Value, true
+						break
+					}
+				}
+			} else if column == "value" {
+				value, found = row, true
+			}
+			if found {
+				cells[i][j] = newTableCell(value)
+			}
+		}
+	}
+
+	border := asciiBorder
+	if cfg.Unicode {
+		border = unicodeBorder
+	}
+
+	widths := columnWidths(cfg, columns, cells)
+
+	var sb strings.Builder
+	writeTableLine(&sb, widths, border.topLeft, border.topMid, border.topRight, border.horizontal)
+	header := make([]tableCell, len(columns))
+	for i, column := range columns {
+		header[i] = tableCell{text: column}
+	}
+	writeTableRow(&sb, widths, header, border)
+	writeTableLine(&sb, widths, border.midLeft, border.midMid, border.midRight, border.horizontal)
+	for _, row := range cells {
+		writeTableRow(&sb, widths, row, border)
+	}
+	writeTableLine(&sb, widths, border.bottomLeft, border.bottomMid, border.bottomRight, border.horizontal)
+
+	return sb.String()
+}
+
+// tableColumns returns the keys of all rows in order of their first appearance
+func tableColumns(rows []interface{}) []string {
+	var columns []string
+	seen := map[string]bool{}
+	for _, row := range rows {
+		object, ok := row.(prettyObject)
+		if !ok {
+			if !seen["value"] {
+				seen["value"] = true
+				columns = append(columns, "value")
+			}
+			continue
+		}
+		for _, entry := range object {
+			if !seen[entry.Key] {
+				seen[entry.Key] = true
+				columns = append(columns, entry.Key)
+			}
+		}
+	}
+	return columns
+}
+
+func newTableCell(value interface{}) tableCell {
+	switch v := value.(type) {
+	case int64, uint64, float64, json.Number:
+		return tableCell{text: yamlScalar(v), numeric: true}
+	case nil:
+		return tableCell{text: "null"}
+	case bool:
+		return tableCell{text: yamlScalar(v)}
+	}
+	return tableCell{text: strings.ReplaceAll(toString(value), "\n", " ")}
+}
+
+// columnWidths calculates the width of each column and shrinks the widest columns until the table fits MaxWidth
+func columnWidths(cfg TableConfig, columns []string, cells [][]tableCell) []int {
+	widths := make([]int, len(columns))
+	for i, column := range columns {
+		widths[i] = utf8.RuneCountInString(column)
+	}
+	for _, row := range cells {
+		for i, cell := range row {
+			if w := utf8.RuneCountInString(cell.text); w > widths[i] {
+				widths[i] = w
+			}
+		}
+	}
+
+	if cfg.MaxColumnWidth > 0 {
+		for i := range widths {
+			if widths[i] > cfg.MaxColumnWidth {
+				widths[i] = cfg.MaxColumnWidth
+			}
+		}
+	}
+
+	if cfg.MaxWidth > 0 {
+		const minWidth = 3
+		for {
+			total := 1
+			widest := 0
+			for i, w := range widths {
+				total += w + 3
+				if w > widths[widest] {
+					widest = i
+				}
+			}
+			if total <= cfg.MaxWidth || widths[widest] <= minWidth {
+				break
+			}
+			widths[widest]--
+		}
+	}
+
+	return widths
+}
+
+func writeTableLine(sb *strings.Builder, widths []int, left, mid, right, horizontal string) {
+	sb.WriteString(left)
+	for i, w := range widths {
+		if i > 0 {
+			sb.WriteString(mid)
+		}
+		sb.WriteString(strings.Repeat(horizontal, w+2))
+	}
+	sb.WriteString(right)
+	sb.WriteString("\n")
+}
+
+func writeTableRow(sb *strings.Builder, widths []int, row []tableCell, border tableBorder) {
+	sb.WriteString(border.vertical)
+	for i, cell := range row {
+		text := truncate(cell.text, widths[i], border.ellipsis)
+		padding := strings.Repeat(" ", widths[i]-utf8.RuneCountInString(text))
+
+		sb.WriteString(" ")
+		if cell.numeric {
+			sb.WriteString(padding)
+			sb.WriteString(text)
+		} else {
+			sb.WriteString(text)
+			sb.WriteString(padding)
+		}
+		sb.WriteString(" ")
+		sb.WriteString(border.vertical)
+	}
+	sb.WriteString("\n")
+}
+
+// truncate shortens the text to the given width and marks the truncation with the ellipsis
+func truncate(text string, width int, ellipsis string) string {
+	if utf8.RuneCountInString(text) <= width {
+		return text
+	}
+
+	runes := []rune(text)
+	ellipsisWidth := utf8.RuneCountInString(ellipsis)
+	if width <= ellipsisWidth {
+		return string(runes[:width])
+	}
+	return string(runes[:width-ellipsisWidth]) + ellipsis
+}
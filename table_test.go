@@ -0,0 +1,64 @@
+package log
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type row struct {
+	ID   int
+	Name string
+	Tags []string
+}
+
+func TestRenderTable(t *testing.T) {
+	rows := []row{
+		{ID: 1, Name: "bob", Tags: []string{"admin"}},
+		{ID: 42, Name: "alice"},
+	}
+
+	expected := "\n" +
+		"+----+-------+-----------+\n" +
+		"| ID | Name  | Tags      |\n" +
+		"+----+-------+-----------+\n" +
+		"|  1 | bob   | [\"admin\"] |\n" +
+		"| 42 | alice | null      |\n" +
+		"+----+-------+-----------+"
+
+	assert.Equal(t, expected, renderTables(TableConfig{MaxWidth: 80}, []interface{}{rows}))
+}
+
+func TestRenderTableColumnsAndTruncation(t *testing.T) {
+	rows := []map[string]interface{}{
+		{"name": "a very long name", "id": 1},
+		{"name": "short", "extra": true},
+	}
+
+	expected := "\n" +
+		"┌───────┬────┐\n" +
+		"│ name  │ id │\n" +
+		"├───────┼────┤\n" +
+		"│ a ve… │  1 │\n" +
+		"│ short │    │\n" +
+		"└───────┴────┘"
+
+	cfg := TableConfig{Columns: []string{"name", "id"}, MaxColumnWidth: 5, Unicode: true, MaxWidth: 80}
+	assert.Equal(t, expected, renderTables(cfg, []interface{}{rows}))
+
+	narrow := renderTables(TableConfig{MaxWidth: 20}, []interface{}{rows})
+	for _, line := range strings.Split(strings.TrimPrefix(narrow, "\n"), "\n") {
+		assert.Len(t, line, 20)
+	}
+	assert.Contains(t, narrow, "...")
+}
+
+func TestPrettyPrintTable(t *testing.T) {
+	messages := captureMessages(t)
+
+	PrettyPrintTable(INFO, []int{1, 2}, TableConfig{Columns: []string{"value"}, MaxWidth: 80})
+
+	assert.Len(t, *messages, 1)
+	assert.Equal(t, "\n+-------+\n| value |\n+-------+\n|     1 |\n|     2 |\n+-------+\n", (*messages)[0].Message)
+}
@@ -0,0 +1,161 @@
+package test
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"strings"
+	"testing"
+
+	log "github.com/chris-dot-exe/AwesomeLog"
+)
+
+// line returns the line number of its caller
+func line() int {
+	_, _, l, _ := runtime.Caller(1)
+	return l
+}
+
+// capture sets a handler collecting all messages and returns a function returning the last message
+func capture(t *testing.T) func() log.Message {
+	t.Helper()
+
+	var last log.Message
+	cfg := log.DefaultLevelConfig()
+	cfg.Info.SetHandlers([]log.Handler{func(message log.Message) {
+		last = message
+	}})
+	log.SetLevelConfig(cfg)
+	log.SetLogLevel(log.VERBOSE)
+	log.ShowCaller(true)
+	log.ShowColors(false)
+	log.ShowTimestamp(false)
+	log.SetCallerMaxDepth(0)
+
+	t.Cleanup(func() {
+		log.SetLevelConfig(log.DefaultLevelConfig())
+		log.ResetGates()
+	})
+
+	return func() log.Message {
+		return last
+	}
+}
+
+func assertCaller(t *testing.T, message log.Message, expectedLine int) {
+	t.Helper()
+
+	if message.Caller.LineNumber != expectedLine || message.Caller.Path != "entrypoint_test.go" {
+		t.Errorf("expected caller entrypoint_test.go:%d, got %s:%d", expectedLine, message.Caller.Path, message.Caller.LineNumber)
+	}
+}
+
+func assertCallerInString(t *testing.T, output string, expectedLine int) {
+	t.Helper()
+
+	expected := fmt.Sprintf(":%d]", expectedLine)
+	if !strings.HasPrefix(output, "[INFO][entrypoint_test.go:") || !strings.Contains(output, expected) {
+		t.Errorf("expected %s in %q", expected, output)
+	}
+}
+
+func TestEntryPointsReportUserLine(t *testing.T) {
+	last := capture(t)
+	logger := log.New()
+
+	tests := map[string]func() int{
+		"Println":                 func() int { log.Println("msg"); return line() },
+		"Print":                   func() int { log.Print("msg"); return line() },
+		"Printf":                  func() int { log.Printf("%s", "msg"); return line() },
+		"PrettyPrint":             func() int { log.PrettyPrint("msg"); return line() },
+		"Dump":                    func() int { log.Dump("msg"); return line() },
+		"PrettyPrintYAML":         func() int { log.PrettyPrintYAML("msg"); return line() },
+		"PrettyPrintTable":        func() int { log.PrettyPrintTable("msg"); return line() },
+		"Logger.Println":          func() int { logger.Println("msg"); return line() },
+		"Logger.Print":            func() int { logger.Print("msg"); return line() },
+		"Logger.Printf":           func() int { logger.Printf("%s", "msg"); return line() },
+		"Logger.PrettyPrint":      func() int { logger.PrettyPrint("msg"); return line() },
+		"Logger.Dump":             func() int { logger.Dump("msg"); return line() },
+		"Logger.PrettyPrintYAML":  func() int { logger.PrettyPrintYAML("msg"); return line() },
+		"Logger.PrettyPrintTable": func() int { logger.PrettyPrintTable("msg"); return line() },
+		"Log":                     func() int { log.Log(log.INFO, "msg"); return line() },
+		"Logger.Log":              func() int { logger.Log(log.INFO, "msg"); return line() },
+		"With":                    func() int { log.With(log.Int("n", 1)).Println("msg"); return line() },
+		"Logger.With":             func() int { logger.With(log.Int("n", 1)).Log(log.INFO, "msg"); return line() },
+		"Named":                   func() int { log.Named("probe").Println("msg"); return line() },
+		"Logger.Named":            func() int { logger.Named("probe").Log(log.INFO, "msg"); return line() },
+		"WithContext":             func() int { log.WithContext(context.Background()).Println("msg"); return line() },
+		"Logger.WithContext":      func() int { logger.WithContext(context.Background()).Log(log.INFO, "msg"); return line() },
+		"LogOnce":                 func() int { log.LogOnce().Println("msg"); return line() },
+		"Logger.LogOnce":          func() int { logger.LogOnce().Log(log.INFO, "msg"); return line() },
+	}
+
+	for name, call := range tests {
+		t.Run(name, func(t *testing.T) {
+			expectedLine := call()
+			assertCaller(t, last(), expectedLine)
+		})
+	}
+}
+
+func TestStringEntryPointsReportUserLine(t *testing.T) {
+	capture(t)
+	logger := log.New()
+
+	tests := map[string]func() (string, int){
+		"Sprintln":                 func() (string, int) { return log.Sprintln("msg"), line() },
+		"Sprint":                   func() (string, int) { return log.Sprint("msg"), line() },
+		"Sprintf":                  func() (string, int) { return log.Sprintf("%s", "msg"), line() },
+		"SprettyPrint":             func() (string, int) { return log.SprettyPrint("msg"), line() },
+		"Sdump":                    func() (string, int) { return log.Sdump("msg"), line() },
+		"SprettyPrintYAML":         func() (string, int) { return log.SprettyPrintYAML("msg"), line() },
+		"SprettyPrintTable":        func() (string, int) { return log.SprettyPrintTable("msg"), line() },
+		"Logger.Sprintln":          func() (string, int) { return logger.Sprintln("msg"), line() },
+		"Logger.Sprint":            func() (string, int) { return logger.Sprint("msg"), line() },
+		"Logger.Sprintf":           func() (string, int) { return logger.Sprintf("%s", "msg"), line() },
+		"Logger.SprettyPrint":      func() (string, int) { return logger.SprettyPrint("msg"), line() },
+		"Logger.Sdump":             func() (string, int) { return logger.Sdump("msg"), line() },
+		"Logger.SprettyPrintYAML":  func() (string, int) { return logger.SprettyPrintYAML("msg"), line() },
+		"Logger.SprettyPrintTable": func() (string, int) { return logger.SprettyPrintTable("msg"), line() },
+	}
+
+	for name, call := range tests {
+		t.Run(name, func(t *testing.T) {
+			output, expectedLine := call()
+			assertCallerInString(t, output, expectedLine)
+		})
+	}
+}
+
+// logWrapper is a team wrapper around Println
+func logWrapper(msg string) {
+	log.WithCallerSkip(1).Println(log.INFO, msg)
+}
+
+// helperWrapper is a team wrapper around Println using Helper
+func helperWrapper(msg string) {
+	log.Helper()
+	log.Println(log.INFO, msg)
+}
+
+// nestedHelper calls another helper
+func nestedHelper(msg string) {
+	log.Helper()
+	helperWrapper(msg)
+}
+
+func TestCallerSkip(t *testing.T) {
+	last := capture(t)
+
+	logWrapper("wrapped")
+	assertCaller(t, last(), line()-1)
+
+	helperWrapper("helper")
+	assertCaller(t, last(), line()-1)
+
+	nestedHelper("nested")
+	assertCaller(t, last(), line()-1)
+
+	log.New().WithCallerSkip(1).WithCallerSkip(-1).Println(log.INFO, "no skip")
+	assertCaller(t, last(), line()-1)
+}
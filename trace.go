@@ -0,0 +1,176 @@
+package log
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+const (
+	// TraceparentHeader is the W3C trace context header containing the trace ID and the parent span ID
+	TraceparentHeader = "traceparent"
+	// TracestateHeader is the W3C trace context header containing vendor specific trace data
+	TracestateHeader = "tracestate"
+)
+
+// ErrInvalidTraceparent is returned by ParseTraceparent for malformed traceparent headers
+var ErrInvalidTraceparent = errors.New("invalid traceparent")
+
+// TraceContext contains the W3C trace context of a request
+type TraceContext struct {
+	// TraceID is the 32 character hex encoded ID of the trace
+	TraceID string
+	// SpanID is the 16 character hex encoded ID of the current span
+	SpanID string
+	// ParentID is the ID of the span of the caller, it is empty for new traces
+	ParentID string
+	// Flags are the trace flags, e.g. 01 if the trace is sampled
+	Flags byte
+	// State is the value of the tracestate header, which is propagated unchanged
+	State string
+}
+
+// NewTraceContext returns a TraceContext with a new trace ID and span ID
+func NewTraceContext() TraceContext {
+	return TraceContext{
+		TraceID: randomID(16),
+		SpanID:  randomID(8),
+		Flags:   1,
+	}
+}
+
+// ParseTraceparent parses a traceparent header, e.g. 00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01.
+// The span ID of the header is returned as ParentID and SpanID.
+func ParseTraceparent(header string) (TraceContext, error) {
+	parts := strings.Split(strings.TrimSpace(header), "-")
+	if len(parts) < 4 {
+		return TraceContext{}, fmt.Errorf("%w: %q", ErrInvalidTraceparent, header)
+	}
+
+	version, traceID, spanID, flags := parts[0], parts[1], parts[2], parts[3]
+	if !isHex(version, 2) || version == "ff" || (version == "00" && len(parts) != 4) {
+		return TraceContext{}, fmt.Errorf("%w: unsupported version %q", ErrInvalidTraceparent, version)
+	}
+	if !isHex(traceID, 32) || traceID == strings.Repeat("0", 32) {
+		return TraceContext{}, fmt.Errorf("%w: invalid trace-id %q", ErrInvalidTraceparent, traceID)
+	}
+	if !isHex(spanID, 16) || spanID == strings.Repeat("0", 16) {
+		return TraceContext{}, fmt.Errorf("%w: invalid parent-id %q", ErrInvalidTraceparent, spanID)
+	}
+	if !isHex(flags, 2) {
+		return TraceContext{}, fmt.Errorf("%w: invalid trace-flags %q", ErrInvalidTraceparent, flags)
+	}
+
+	decoded, _ := hex.DecodeString(flags)
+	return TraceContext{
+		TraceID:  traceID,
+		SpanID:   spanID,
+		ParentID: spanID,
+		Flags:    decoded[0],
+	}, nil
+}
+
+// Traceparent returns the traceparent header of the TraceContext with SpanID as parent-id
+func (t TraceContext) Traceparent() string {
+	return "00-" + t.TraceID + "-" + t.SpanID + "-" + hex.EncodeToString([]byte{t.Flags})
+}
+
+// Child returns a TraceContext of the same trace with a new span ID and the current span as parent
+func (t TraceContext) Child() TraceContext {
+	child := t
+	child.ParentID = t.SpanID
+	child.SpanID = randomID(8)
+	return child
+}
+
+// WithTraceContext returns a context containing the TraceContext.
+// Loggers bound to the context with WithContext add the fields trace_id and span_id to every message.
+func WithTraceContext(ctx context.Context, trace TraceContext) context.Context {
+	return context.WithValue(ctx, traceKey, trace)
+}
+
+// TraceContextFromContext returns the TraceContext stored with WithTraceContext
+func TraceContextFromContext(ctx context.Context) (TraceContext, bool) {
+	trace, ok := ctx.Value(traceKey).(TraceContext)
+	return trace, ok
+}
+
+// Tracing returns a HTTP middleware which continues the trace of the traceparent header of the request
+// or starts a new trace and stores the TraceContext of the request in the request context
+func Tracing() func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			trace, err := ParseTraceparent(r.Header.Get(TraceparentHeader))
+			if err != nil {
+				trace = NewTraceContext()
+			} else {
+				trace = trace.Child()
+				trace.State = r.Header.Get(TracestateHeader)
+			}
+
+			next.ServeHTTP(w, r.WithContext(WithTraceContext(r.Context(), trace)))
+		})
+	}
+}
+
+// InjectTraceContext sets the traceparent and tracestate headers of an outgoing request
+// from the TraceContext of ctx. The request is not changed if ctx does not contain a TraceContext.
+func InjectTraceContext(ctx context.Context, r *http.Request) {
+	trace, ok := TraceContextFromContext(ctx)
+	if !ok {
+		return
+	}
+
+	r.Header.Set(TraceparentHeader, trace.Traceparent())
+	if trace.State != "" {
+		r.Header.Set(TracestateHeader, trace.State)
+	}
+}
+
+// TraceTransport returns a http.RoundTripper which propagates the TraceContext of the request context
+// to outgoing requests. If base is nil http.DefaultTransport is used.
+func TraceTransport(base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return traceTransport{base: base}
+}
+
+type traceTransport struct {
+	base http.RoundTripper
+}
+
+func (t traceTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	if _, ok := TraceContextFromContext(r.Context()); ok {
+		// a RoundTripper must not modify the original request
+		r = r.Clone(r.Context())
+		InjectTraceContext(r.Context(), r)
+	}
+	return t.base.RoundTrip(r)
+}
+
+// randomID returns a random hex encoded ID of n bytes
+func randomID(n int) string {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		panic(fmt.Sprintf("could not generate trace ID: %v", err))
+	}
+	return hex.EncodeToString(b)
+}
+
+// isHex returns if s consists of n lowercase hex characters
+func isHex(s string, n int) bool {
+	if len(s) != n {
+		return false
+	}
+	for _, c := range s {
+		if (c < '0' || c > '9') && (c < 'a' || c > 'f') {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,96 @@
+package log
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseTraceparent(t *testing.T) {
+	trace, err := ParseTraceparent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	assert.NoError(t, err)
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", trace.TraceID)
+	assert.Equal(t, "00f067aa0ba902b7", trace.SpanID)
+	assert.Equal(t, byte(1), trace.Flags)
+	assert.Equal(t, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", trace.Traceparent())
+
+	_, err = ParseTraceparent("01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-future")
+	assert.NoError(t, err)
+
+	for _, header := range []string{
+		"",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7",
+		"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		"00-00000000000000000000000000000000-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01",
+		"00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra",
+	} {
+		_, err := ParseTraceparent(header)
+		assert.True(t, errors.Is(err, ErrInvalidTraceparent), header)
+	}
+}
+
+func TestTracingMiddleware(t *testing.T) {
+	messages := captureMessages(t)
+
+	var trace TraceContext
+	handler := Tracing()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		trace, _ = TraceContextFromContext(r.Context())
+		WithContext(r.Context()).Println(INFO, "handled")
+	}))
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set(TraceparentHeader, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	r.Header.Set(TracestateHeader, "vendor=value")
+	handler.ServeHTTP(httptest.NewRecorder(), r)
+
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", trace.TraceID)
+	assert.Equal(t, "00f067aa0ba902b7", trace.ParentID)
+	assert.NotEqual(t, "00f067aa0ba902b7", trace.SpanID)
+	assert.Equal(t, "vendor=value", trace.State)
+
+	traceID, _ := (*messages)[0].Field("trace_id")
+	spanID, _ := (*messages)[0].Field("span_id")
+	assert.Equal(t, trace.TraceID, traceID)
+	assert.Equal(t, trace.SpanID, spanID)
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Len(t, trace.TraceID, 32)
+	assert.Len(t, trace.SpanID, 16)
+	assert.Empty(t, trace.ParentID)
+}
+
+func TestTraceTransport(t *testing.T) {
+	var received http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r.Header
+	}))
+	defer server.Close()
+
+	trace := NewTraceContext()
+	trace.State = "vendor=value"
+	ctx := WithTraceContext(context.Background(), trace)
+
+	client := &http.Client{Transport: TraceTransport(nil)}
+	r, _ := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+	resp, err := client.Do(r)
+	assert.NoError(t, err)
+	_ = resp.Body.Close()
+
+	assert.Equal(t, trace.Traceparent(), received.Get(TraceparentHeader))
+	assert.Equal(t, "vendor=value", received.Get(TracestateHeader))
+	assert.Empty(t, r.Header.Get(TraceparentHeader))
+}
+
+func TestWithContextTraceFields(t *testing.T) {
+	first := WithTraceContext(context.Background(), NewTraceContext())
+	second := WithTraceContext(context.Background(), NewTraceContext())
+
+	logger := WithContext(first).WithContext(second)
+	assert.Len(t, logger.fields, 2)
+}
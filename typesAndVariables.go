@@ -1,29 +1,54 @@
 package log
 
-import "time"
+import (
+	"context"
+	"time"
+)
 
 type LogLevel uint
 
 // String returns the LogLevel name as string
 func (t *LogLevel) String() string {
-	for k, v := range level {
-		if *t == v {
-			return k
-		}
+	switch *t {
+	case CRITICAL:
+		return "CRITICAL"
+	case ERROR:
+		return "ERROR"
+	case WARN:
+		return "WARN"
+	case INFO:
+		return "INFO"
+	case DEBUG:
+		return "DEBUG"
+	case VERBOSE:
+		return "VERBOSE"
 	}
 	return "NONE"
 }
 
 // Color returns the defined color of the LogLevel
 func (t *LogLevel) Color() string {
-	return lvlColor[*t]
+	switch *t {
+	case CRITICAL:
+		return ANSI_PURPLE_BACKGROUND + ANSI_WHITE
+	case ERROR, DEBUG:
+		return ANSI_RED_BACKGROUND + ANSI_WHITE
+	case WARN:
+		return ANSI_YELLOW_BACKGROUND + ANSI_BLACK
+	case INFO:
+		return ANSI_BLUE_BACKGROUND + ANSI_WHITE
+	}
+	return ""
 }
 
-// Caller contains the Caller information file path, function name and line number
+// Caller contains the Caller information file path, function name and line number.
+// Function contains the package-qualified function name and File the absolute file path.
 type Caller struct {
 	Path         string
 	FunctionName string
 	LineNumber   int
+	Function     string
+	File         string
 }
 
 func (c Caller) ToMap() map[string]interface{} {
@@ -31,26 +56,98 @@ func (c Caller) ToMap() map[string]interface{} {
 		"Path":         c.Path,
 		"FunctionName": c.FunctionName,
 		"LineNumber":   c.LineNumber,
+		"Function":     c.Function,
+		"File":         c.File,
 	}
 }
 
+// Frame is a single frame of a stack trace
+type Frame struct {
+	Function string
+	File     string
+	Line     int
+}
+
+// Field is a key value pair attached to a Message.
+// Fields created with the typed constructors like String or Int store their value without interface boxing.
+type Field struct {
+	Key  string
+	kind fieldKind
+	// nanos are the nanoseconds of a time value, the seconds are stored in integer
+	nanos   int32
+	integer int64
+	str     string
+	value   interface{}
+}
+
 // Message is the object which is passed to every handler function.
-// Message contains the LogLevel, the Caller object and the message
+// Message contains the LogLevel, the name of the Logger, the Caller object, the message, additional fields,
+// the errors passed as parameters and the stack trace if CaptureStack is enabled for the LogLevel
 type Message struct {
-	Time    time.Time
-	Level   LogLevel
+	Time  time.Time
+	Level LogLevel
+	// Name is the name of the named Logger which logged the message, e.g. db.pool
+	Name    string
 	Caller  Caller
 	Message string
+	Fields  []Field
+	Errors  []ErrorInfo
+	Stack   []Frame
+
+	// pretty is set if Message contains JSON rendered by PrettyPrint
+	pretty bool
+	// formatOnly is set if Message is only formatted by the Sprint functions and not passed to any handler
+	formatOnly bool
+	// handlers receive the Message at the end of the middleware chain
+	handlers []Handler
+}
+
+// Field returns the value of the field with the given key
+func (m Message) Field(key string) (interface{}, bool) {
+	for _, f := range m.Fields {
+		if f.Key == key {
+			return f.Value(), true
+		}
+	}
+	return nil, false
+}
+
+// SetField sets the field with the given key or appends it if it does not exist yet.
+// The Fields slice is copied so other copies of the Message are not affected.
+func (m *Message) SetField(key string, value interface{}) {
+	m.setField(Any(key, value))
+}
+
+func (m *Message) setField(field Field) {
+	fields := make([]Field, len(m.Fields), len(m.Fields)+1)
+	copy(fields, m.Fields)
+	m.Fields = fields
+
+	for i := range m.Fields {
+		if m.Fields[i].Key == field.Key {
+			m.Fields[i] = field
+			return
+		}
+	}
+	m.Fields = append(m.Fields, field)
 }
 
 type Handler func(message Message)
 
+// Middleware wraps a Handler and returns a new Handler.
+// A Middleware may drop, modify or duplicate messages before passing them to the next Handler.
+type Middleware func(next Handler) Handler
+
 // LevelConfig represents the configuration for each LogLevel
 type LevelConfig struct {
 	ShowLineNumber   bool
 	ShowFunctionName bool
 	ShowFilePath     bool
+	CaptureStack     bool
 	Handlers         []Handler
+	// Sampler and RateLimiter are optional and drop messages before they are passed to the handlers
+	Sampler     *Sampler
+	RateLimiter *RateLimiter
 }
 
 // AddHandler adds a custom Handler to the existing handlers of the LogLevel
@@ -64,6 +161,21 @@ func (c *LevelConfig) SetHandlers(handler []Handler) {
 	c.Handlers = handler
 }
 
+// Logger provides all log functions of the package.
+// The package level functions use a default Logger.
+type Logger struct {
+	callerSkip int
+	fields     []Field
+	gate       *gate
+	ctx        context.Context
+	buffer     *debugBuffer
+	name       string
+	level      LogLevel
+	hasLevel   bool
+	// hideCaller skips the caller resolution, e.g. for messages of middleware where the caller is meaningless
+	hideCaller bool
+}
+
 // Config represents the config for all LogLevels
 type Config struct {
 	Verbose  LevelConfig
@@ -84,7 +196,8 @@ const (
 	VERBOSE  LogLevel = 100
 )
 
-var logLevel = VERBOSE
+// logLevel is the LogLevel set by SetLogLevel, it is accessed atomically
+var logLevel = uint32(VERBOSE)
 var defaultLevel = INFO
 var colorsInLogs = false
 var showColors = true
@@ -92,6 +205,15 @@ var config *Config
 var showTimestamp = true
 var timeFormat = "2006/01/02 15:04:05"
 var maxDepthOfCallerPath = 0
+var middleware []Middleware
+
+// middlewareChain is the global middleware wrapped around deliver, nil if no middleware is set
+var middlewareChain Handler
+var redactor *Redactor
+
+// std is the Logger used by the package level functions.
+// It skips the package level function when resolving the caller.
+var std = &Logger{callerSkip: 1}
 
 var level = map[string]LogLevel{
 	"NONE":     NONE,
@@ -102,13 +224,3 @@ var level = map[string]LogLevel{
 	"DEBUG":    DEBUG,
 	"VERBOSE":  VERBOSE,
 }
-
-var lvlColor = map[LogLevel]string{
-	NONE:     "",
-	CRITICAL: ANSI_PURPLE_BACKGROUND + ANSI_WHITE,
-	ERROR:    ANSI_RED_BACKGROUND + ANSI_WHITE,
-	WARN:     ANSI_YELLOW_BACKGROUND + ANSI_BLACK,
-	INFO:     ANSI_BLUE_BACKGROUND + ANSI_WHITE,
-	DEBUG:    ANSI_RED_BACKGROUND + ANSI_WHITE,
-	VERBOSE:  "",
-}
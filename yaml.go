@@ -0,0 +1,166 @@
+package log
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+)
+
+// PrettyPrintYAML logs a message at the defined LogLevel formatted as YAML.
+// The same struct tags as for PrettyPrint are honored.
+func PrettyPrintYAML(params ...interface{}) {
+	std.PrettyPrintYAML(params...)
+}
+
+// SprettyPrintYAML returns the log message of PrettyPrintYAML as string
+func SprettyPrintYAML(params ...interface{}) string {
+	return std.SprettyPrintYAML(params...)
+}
+
+// PrettyPrintYAML logs a message at the defined LogLevel formatted as YAML
+func (l *Logger) PrettyPrintYAML(params ...interface{}) {
+	level, _, params := getLogLevel(false, params...)
+	if l.skip(level, callPrintln, "", params, nil) {
+		return
+	}
+	params = evaluateLazy(params)
+	l.println(level, renderYAML(params))
+}
+
+// SprettyPrintYAML returns the log message of PrettyPrintYAML as string
+func (l *Logger) SprettyPrintYAML(params ...interface{}) string {
+	level, _, params := getLogLevel(false, params...)
+	if !l.shows(level) {
+		return ""
+	}
+	params = evaluateLazy(params)
+	return l.sprintln(level, renderYAML(params))
+}
+
+// renderYAML renders every value as its own YAML document.
+// Values which can not be rendered are rendered like Dump.
+func renderYAML(values []interface{}) string {
+	documents := make([]string, 0, len(values))
+	for _, value := range values {
+		tree, err := toPretty(value)
+		if err != nil {
+			return sdump(dumpConfig, values...)
+		}
+
+		var sb strings.Builder
+		writeYAML(&sb, tree, 0)
+		documents = append(documents, strings.TrimSuffix(sb.String(), "\n"))
+	}
+	return strings.Join(documents, "\n---\n")
+}
+
+// writeYAML writes the value in block style, nested values are indented by two spaces
+func writeYAML(sb *strings.Builder, value interface{}, depth int) {
+	indent := strings.Repeat("  ", depth)
+
+	switch v := value.(type) {
+	case prettyObject:
+		if len(v) == 0 {
+			sb.WriteString("{}\n")
+			return
+		}
+		for i, entry := range v {
+			if i > 0 {
+				sb.WriteString(indent)
+			}
+			sb.WriteString(yamlScalar(entry.Key))
+			sb.WriteString(":")
+			writeYAMLChild(sb, entry.Value, depth)
+		}
+	case []interface{}:
+		if len(v) == 0 {
+			sb.WriteString("[]\n")
+			return
+		}
+		for i, item := range v {
+			if i > 0 {
+				sb.WriteString(indent)
+			}
+			sb.WriteString("- ")
+			writeYAML(sb, item, depth+1)
+		}
+	default:
+		sb.WriteString(yamlScalar(v))
+		sb.WriteString("\n")
+	}
+}
+
+// writeYAMLChild writes the value of an object key
+func writeYAMLChild(sb *strings.Builder, value interface{}, depth int) {
+	switch v := value.(type) {
+	case prettyObject:
+		if len(v) > 0 {
+			sb.WriteString("\n")
+			sb.WriteString(strings.Repeat("  ", depth+1))
+			writeYAML(sb, v, depth+1)
+			return
+		}
+	case []interface{}:
+		if len(v) > 0 {
+			sb.WriteString("\n")
+			sb.WriteString(strings.Repeat("  ", depth+1))
+			writeYAML(sb, v, depth+1)
+			return
+		}
+	}
+	sb.WriteString(" ")
+	writeYAML(sb, value, depth+1)
+}
+
+// yamlScalar formats a scalar value, strings are quoted if they would be interpreted differently
+func yamlScalar(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return strconv.FormatBool(v)
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case uint64:
+		return strconv.FormatUint(v, 10)
+	case float64:
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	case json.Number:
+		return v.String()
+	case string:
+		if yamlNeedsQuotes(v) {
+			return strconv.Quote(v)
+		}
+		return v
+	}
+	return strconv.Quote(toString(value))
+}
+
+func yamlNeedsQuotes(str string) bool {
+	if str == "" || strings.TrimSpace(str) != str {
+		return true
+	}
+	switch strings.ToLower(str) {
+	case "true", "false", "yes", "no", "on", "off", "null", "~":
+		return true
+	}
+	if _, err := strconv.ParseFloat(str, 64); err == nil {
+		return true
+	}
+	if strings.ContainsAny(str[:1], "-?:,[]{}#&*!|>'\"%@`") {
+		return true
+	}
+	return strings.Contains(str, ": ") || strings.Contains(str, " #") || strings.ContainsAny(str, "\n\t\r")
+}
+
+// toString renders a pretty tree value in a single line
+func toString(value interface{}) string {
+	if str, ok := value.(string); ok {
+		return str
+	}
+	var sb strings.Builder
+	if err := writePrettyJSON(&sb, value, "", 0); err != nil {
+		return ""
+	}
+	return sb.String()
+}
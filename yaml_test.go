@@ -0,0 +1,63 @@
+package log
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type service struct {
+	Name     string
+	Port     int
+	Enabled  bool
+	Version  string
+	Hosts    []string
+	Limits   map[string]float64
+	Owner    *credentials
+	Replicas []service `log:"omitempty"`
+}
+
+func TestRenderYAML(t *testing.T) {
+	svc := service{
+		Name:    "api",
+		Port:    8080,
+		Enabled: true,
+		Version: "1.10",
+		Hosts:   []string{"a.example.com", "- b"},
+		Limits:  map[string]float64{"cpu": 0.5},
+		Replicas: []service{
+			{Name: "replica", Hosts: []string{}},
+		},
+	}
+
+	expected := "Name: api\n" +
+		"Port: 8080\n" +
+		"Enabled: true\n" +
+		"Version: \"1.10\"\n" +
+		"Hosts:\n" +
+		"  - a.example.com\n" +
+		"  - \"- b\"\n" +
+		"Limits:\n" +
+		"  cpu: 0.5\n" +
+		"Owner: null\n" +
+		"Replicas:\n" +
+		"  - Name: replica\n" +
+		"    Port: 0\n" +
+		"    Enabled: false\n" +
+		"    Version: \"\"\n" +
+		"    Hosts: []\n" +
+		"    Limits: null\n" +
+		"    Owner: null"
+
+	assert.Equal(t, expected, renderYAML([]interface{}{svc}))
+	assert.Equal(t, "1\n---\n- a", renderYAML([]interface{}{1, []string{"a"}}))
+}
+
+func TestPrettyPrintYAML(t *testing.T) {
+	messages := captureMessages(t)
+
+	PrettyPrintYAML(INFO, map[string]string{"password": "hunter2", "user": "bob"})
+
+	assert.Len(t, *messages, 1)
+	assert.Equal(t, "password: hunter2\nuser: bob\n", (*messages)[0].Message)
+}